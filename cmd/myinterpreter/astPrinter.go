@@ -43,11 +43,8 @@ func (printer astPrinter) visitLiteralExpr(expr *LiteralExpr) string {
 	if expr.value == nil {
 		return "nil"
 	}
-	switch v := expr.value.(type) {
-	case float64:
-		if expr.value == float64(int(v)) {
-			return fmt.Sprintf("%.1f", expr.value)
-		}
+	if isNumber(expr.value) {
+		return formatLiteral(expr.value)
 	}
 	return fmt.Sprint(expr.value)
 }
@@ -57,7 +54,7 @@ func (printer astPrinter) visitVarExpr(expr *VarExpr) string {
 }
 
 func (printer astPrinter) visitAssignExpr(expr *AssignExpr) string {
-	return fmt.Sprintf("var %v=%v\n", expr.name, expr.value)
+	return fmt.Sprintf("var %v%v%v\n", expr.name.Lexeme, expr.operator.Lexeme, expr.value.print(printer))
 }
 
 func (printer astPrinter) visitLogicalExpr(expr *LogicalExpr) string {
@@ -73,7 +70,7 @@ func (printer astPrinter) visitGetExpr(expr *GetExpr) string {
 }
 
 func (printer astPrinter) visitSetExpr(expr *SetExpr) string {
-	return fmt.Sprintf("set on %v, value=%v\n", expr.object.print(printer), expr.value.print(printer))
+	return fmt.Sprintf("set on %v, value%v%v\n", expr.object.print(printer), expr.operator.Lexeme, expr.value.print(printer))
 }
 
 func (printer astPrinter) visitThisExpr(expr *ThisExpr) string {
@@ -98,3 +95,58 @@ func (printer astPrinter) visitArrayDeclExpr(expr *ArrayDeclExpr) string {
 func (printer astPrinter) visitSubscriptExpr(expr *SubscriptExpr) string {
 	return fmt.Sprintf("subscript index %v", expr.index.print(printer))
 }
+
+func (printer astPrinter) visitSubscriptSetExpr(expr *SubscriptSetExpr) string {
+	return fmt.Sprintf("subscript set index %v, value%v%v", expr.index.print(printer), expr.operator.Lexeme, expr.value.print(printer))
+}
+
+func (printer astPrinter) visitTernaryExpr(expr *TernaryExpr) string {
+	return printer.parenthesize("?:", expr.condition, expr.thenBranch, expr.elseBranch)
+}
+
+func (printer astPrinter) visitOptionalChainExpr(expr *OptionalChainExpr) string {
+	return printer.parenthesize("?.", expr.expr)
+}
+
+func (printer astPrinter) visitDestructureAssignExpr(expr *DestructureAssignExpr) string {
+	b := strings.Builder{}
+	b.WriteString("destructure [ ")
+	for _, target := range expr.targets {
+		b.WriteString(target.print(printer))
+		b.WriteString(" ")
+	}
+	b.WriteString("] = ")
+	b.WriteString(expr.value.print(printer))
+	return b.String()
+}
+
+func (printer astPrinter) visitSliceExpr(expr *SliceExpr) string {
+	bounds := make([]string, 0, 3)
+	for _, bound := range []Expr{expr.start, expr.end, expr.step} {
+		if bound == nil {
+			bounds = append(bounds, "nil")
+		} else {
+			bounds = append(bounds, bound.print(printer))
+		}
+	}
+	return fmt.Sprintf("slice %v", strings.Join(bounds, ":"))
+}
+
+func (printer astPrinter) visitSpreadExpr(expr *SpreadExpr) string {
+	return printer.parenthesize("...", expr.value)
+}
+
+func (printer astPrinter) visitYieldExpr(expr *YieldExpr) string {
+	if expr.value == nil {
+		return "(yield)"
+	}
+	return printer.parenthesize("yield", expr.value)
+}
+
+func (printer astPrinter) visitSpawnExpr(expr *SpawnExpr) string {
+	return printer.parenthesize("spawn", expr.call)
+}
+
+func (printer astPrinter) visitAwaitExpr(expr *AwaitExpr) string {
+	return printer.parenthesize("await", expr.value)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tasks started with spawn run on their own goroutine with their own
+// copy of Interpreter (the call stack), scopes and objects are shared.
+
+// LoxTask is returned by spawn, join waits for the result.
+// A runtime error ends only the task, join returns it as an error.
+type LoxTask struct {
+	id      int64
+	callee  any
+	done    chan struct{}
+	result  any
+	failure *runtimeError
+}
+
+func NewLoxTask(id int64, callee any) *LoxTask {
+	return &LoxTask{
+		id:     id,
+		callee: callee,
+		done:   make(chan struct{}),
+	}
+}
+
+func (t *LoxTask) String() string {
+	return fmt.Sprintf("<task %v>", t.callee)
+}
+
+// taskGroup keeps the tasks whose outcome nobody has seen yet: the ones
+// still running and the failed ones that weren't joined. main.go reports
+// them when the script ends instead of dropping them silently.
+type taskGroup struct {
+	mu     sync.Mutex
+	nextId int64
+	tasks  map[*LoxTask]bool
+}
+
+func newTaskGroup() *taskGroup {
+	return &taskGroup{tasks: make(map[*LoxTask]bool)}
+}
+
+func (g *taskGroup) spawn(callee any) *LoxTask {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextId++
+	task := NewLoxTask(g.nextId, callee)
+	g.tasks[task] = true
+	return task
+}
+
+func (g *taskGroup) forget(task *LoxTask) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.tasks, task)
+}
+
+// report prints the failures nobody joined and the tasks still running,
+// it returns false when a task failed.
+func (g *taskGroup) report() bool {
+	g.mu.Lock()
+	tasks := make([]*LoxTask, 0, len(g.tasks))
+	for task := range g.tasks {
+		tasks = append(tasks, task)
+	}
+	g.mu.Unlock()
+	sort.Slice(tasks, func(a, b int) bool { return tasks[a].id < tasks[b].id })
+	ok := true
+	for _, task := range tasks {
+		select {
+		case <-task.done:
+			fmt.Fprintf(os.Stderr, "%v failed: %v\n", task, task.failure.message)
+			ok = false
+		default:
+			fmt.Fprintf(os.Stderr, "%v is still running at exit\n", task)
+		}
+	}
+	return ok
+}
+
+func (i Interpreter) visitSpawnExpr(expr *SpawnExpr) any {
+	callee, arguments := i.evaluateCall(expr.call)
+	task := i.tasks.spawn(callee)
+	i.generator, i.coroutine = nil, nil
+	go func() {
+		defer close(task.done)
+		defer func() {
+			if err := recover(); err != nil {
+				failure, ok := err.(runtimeError)
+				if !ok {
+					panic(err)
+				}
+				task.failure = &failure
+			}
+		}()
+		task.result = i.callValue(expr.call.caleeToken, callee, arguments)
+		i.tasks.forget(task)
+	}()
+	return task
+}
+
+func (i Interpreter) taskMethod(receiver *LoxTask, name Token) any {
+	method, exist := taskMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on task", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+var taskMethods = map[string]nativeMethod{
+	// join waits for the task and returns what the function returned,
+	// or an error when the task was ended by a runtime error
+	"join": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		task := m.receiver.(*LoxTask)
+		<-task.done
+		if task.failure != nil {
+			i.tasks.forget(task)
+			return NewLoxError(task.failure.message)
+		}
+		return task.result
+	}},
+	"isDone": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		select {
+		case <-m.receiver.(*LoxTask).done:
+			return true
+		default:
+			return false
+		}
+	}},
+}
+
+// LoxChannel is created by channel() or channel(capacity).
+type LoxChannel struct {
+	ch chan any
+}
+
+func NewLoxChannel(capacity int) *LoxChannel {
+	return &LoxChannel{ch: make(chan any, capacity)}
+}
+
+func (c *LoxChannel) String() string {
+	return fmt.Sprintf("<channel %v/%v>", len(c.ch), cap(c.ch))
+}
+
+func (i Interpreter) channelMethod(receiver *LoxChannel, name Token) any {
+	method, exist := channelMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on channel", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+// recoverClosed turns panics of sending to or closing a closed channel into runtime errors.
+func (m *NativeMethod) recoverClosed(i Interpreter, msg string) {
+	if err := recover(); err != nil {
+		i.error(m.name, msg)
+	}
+}
+
+var channelMethods = map[string]nativeMethod{
+	// send blocks until the value is received or buffered
+	"send": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		defer m.recoverClosed(i, "Send on closed channel")
+		m.receiver.(*LoxChannel).ch <- args[0]
+		return nil
+	}},
+	// receive blocks until a value is sent, it returns nil when the channel is closed
+	"receive": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return <-m.receiver.(*LoxChannel).ch
+	}},
+	"close": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		defer m.recoverClosed(i, "Channel is already closed")
+		close(m.receiver.(*LoxChannel).ch)
+		return nil
+	}},
+}
+
+// LoxMutex is created by mutex(), a buffered channel is used
+// instead of sync.Mutex so unlocking an unlocked mutex is a runtime error.
+type LoxMutex struct {
+	ch chan struct{}
+}
+
+func NewLoxMutex() *LoxMutex {
+	return &LoxMutex{ch: make(chan struct{}, 1)}
+}
+
+func (mu *LoxMutex) String() string {
+	return "<mutex>"
+}
+
+func (i Interpreter) mutexMethod(receiver *LoxMutex, name Token) any {
+	method, exist := mutexMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on mutex", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+func (m *NativeMethod) unlock(i Interpreter) {
+	select {
+	case <-m.receiver.(*LoxMutex).ch:
+	default:
+		i.error(m.name, "Unlock of unlocked mutex")
+	}
+}
+
+var mutexMethods = map[string]nativeMethod{
+	"lock": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		m.receiver.(*LoxMutex).ch <- struct{}{}
+		return nil
+	}},
+	"unlock": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		m.unlock(i)
+		return nil
+	}},
+	"tryLock": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		select {
+		case m.receiver.(*LoxMutex).ch <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+	}},
+	// withLock(fn) calls fn holding the lock and returns its result
+	"withLock": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		m.receiver.(*LoxMutex).ch <- struct{}{}
+		defer m.unlock(i)
+		return i.callValue(m.name, args[0], []any{})
+	}},
+}
+
+// millisecondsArg converts a non negative number of milliseconds to duration.
+func (i Interpreter) millisecondsArg(args []any, idx int) time.Duration {
+	if !isNumber(args[idx]) || toFloat(args[idx]) < 0 {
+		i.error(i.callToken, fmt.Sprintf("Argument %v should be a non negative number of milliseconds", idx+1))
+	}
+	return time.Duration(toFloat(args[idx]) * float64(time.Millisecond))
+}
+
+var concurrencyNatives = map[string]*NativeFunction{
+	"channel": NewNativeFunction(0, 1, func(i Interpreter, args []any) any {
+		capacity := int64(0)
+		if len(args) == 1 {
+			var ok bool
+			capacity, ok = args[0].(int64)
+			if !ok || capacity < 0 {
+				i.error(i.callToken, "Channel capacity should be a non negative integer")
+			}
+		}
+		return NewLoxChannel(int(capacity))
+	}),
+	"mutex": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		return NewLoxMutex()
+	}),
+	// select(channels) or select(channels, timeout) waits until one
+	// of the channels has a value and returns [index, value],
+	// value is nil for a closed channel. After timeout milliseconds
+	// it returns nil, timeout 0 doesn't wait at all.
+	"select": NewNativeFunction(1, 2, func(i Interpreter, args []any) any {
+		channels, ok := args[0].(*LoxArray)
+		if !ok {
+			i.error(i.callToken, "Argument 1 should be an array of channels")
+		}
+		elements := channels.snapshot()
+		if len(elements) == 0 && len(args) == 1 {
+			i.error(i.callToken, "Can't select without channels and timeout")
+		}
+		cases := make([]reflect.SelectCase, 0, len(elements)+1)
+		for _, element := range elements {
+			channel, ok := element.(*LoxChannel)
+			if !ok {
+				i.error(i.callToken, "Argument 1 should be an array of channels")
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.ch)})
+		}
+		if len(args) == 2 {
+			if timeout := i.millisecondsArg(args, 1); timeout == 0 {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+			} else {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(timeout))})
+			}
+		}
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == len(elements) {
+			return nil
+		}
+		var received any = nil
+		if ok {
+			received = value.Interface()
+		}
+		return NewLoxArray([]any{int64(chosen), received})
+	}),
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// These tests share values between tasks, run them with go test -race.
+
+func TestTasksShareArrays(t *testing.T) {
+	interp := run(t, `
+var shared = [0];
+var pushed = [];
+fun work(n) {
+    for (var i = 0; i < 200; i = i + 1) {
+        shared[0] += 1;
+        pushed.push(i);
+        if (len(pushed) > 50) pushed.removeAt(0);
+        str(pushed);
+        pushed.slice(0, 1);
+    }
+}
+var tasks = [];
+for (var i = 0; i < 8; i = i + 1) tasks.push(spawn work(i));
+for (var i = 0; i < 8; i = i + 1) tasks[i].join();
+var total = shared[0];
+`)
+	if total := interp.globals.access("total"); total != int64(1600) {
+		t.Fatalf("expected 1600 increments, got %v", total)
+	}
+}
+
+func TestTasksShareDecimalContext(t *testing.T) {
+	interp := run(t, `
+fun divide() {
+    for (var i = 0; i < 100; i = i + 1) decimal("1") / decimal("3");
+}
+fun configure() {
+    for (var i = 0; i < 100; i = i + 1) decimalContext(10 + i, "half_even");
+}
+var a = spawn divide();
+var b = spawn configure();
+var divided = a.join();
+var configured = b.join();
+`)
+	for _, name := range []string{"divided", "configured"} {
+		if err, ok := interp.globals.access(name).(*LoxError); ok {
+			t.Fatalf("%v task failed: %v", name, err.message)
+		}
+	}
+}
+
+func TestTasksShareGenerators(t *testing.T) {
+	interp := run(t, `
+fun count(n) {
+    for (var i = 0; i < n; i = i + 1) yield i;
+}
+var g = count(1000);
+var seen = [0];
+fun drain() {
+    while (g.hasNext()) {
+        if (g.next() != nil) seen[0] += 1;
+    }
+}
+var tasks = [];
+for (var i = 0; i < 4; i = i + 1) tasks.push(spawn drain());
+for (var i = 0; i < 4; i = i + 1) tasks[i].join();
+var total = seen[0];
+`)
+	// next returns nil when another task took the last value after hasNext,
+	// every value is taken exactly once
+	if total := interp.globals.access("total"); total != int64(1000) {
+		t.Fatalf("expected 1000 values, got %v", total)
+	}
+}
+
+func TestFailedTaskIsJoinedAsError(t *testing.T) {
+	interp := run(t, `
+fun fail() {
+    return 1 + nil;
+}
+var result = spawn fail();
+result = result.join();
+`)
+	result, ok := interp.globals.access("result").(*LoxError)
+	if !ok {
+		t.Fatalf("expected an error, got %v", interp.globals.access("result"))
+	}
+	if !strings.Contains(result.message, "[line 3]") {
+		t.Fatalf("expected the error of line 3, got %v", result.message)
+	}
+	if !interp.tasks.report() {
+		t.Fatal("a joined failure should not be reported")
+	}
+}
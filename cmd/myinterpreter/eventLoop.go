@@ -0,0 +1,189 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// EventLoop runs callbacks of resolved promises and timers.
+// main.go runs it after the top level statements until nothing is left,
+// a top level await runs it until the awaited promise is resolved.
+// All callbacks run on the main goroutine, one at a time.
+//
+// With the virtual clock the loop doesn't wait for timers, it moves
+// the time forward to the next timer, so scripts run instantly and
+// deterministically.
+type EventLoop struct {
+	mu      sync.Mutex
+	jobs    []func()
+	timers  timerQueue
+	byId    map[int64]*loopTimer
+	nextId  int64
+	virtual bool
+	now     time.Duration
+	start   time.Time
+	// wake interrupts waiting for a timer when a job or an earlier timer
+	// is added from another task
+	wake chan struct{}
+	// async functions suspended in await, with the token of the await
+	awaiting map[*coroutine]Token
+}
+
+func NewEventLoop() *EventLoop {
+	return &EventLoop{
+		byId:     make(map[int64]*loopTimer),
+		start:    time.Now(),
+		wake:     make(chan struct{}, 1),
+		awaiting: make(map[*coroutine]Token),
+	}
+}
+
+type loopTimer struct {
+	id        int64
+	at        time.Duration
+	interval  time.Duration
+	fn        func()
+	cancelled bool
+}
+
+// timerQueue is a heap of timers ordered by time, then by creation.
+type timerQueue []*loopTimer
+
+func (q timerQueue) Len() int { return len(q) }
+func (q timerQueue) Less(a, b int) bool {
+	if q[a].at != q[b].at {
+		return q[a].at < q[b].at
+	}
+	return q[a].id < q[b].id
+}
+func (q timerQueue) Swap(a, b int) { q[a], q[b] = q[b], q[a] }
+func (q *timerQueue) Push(x any)   { *q = append(*q, x.(*loopTimer)) }
+func (q *timerQueue) Pop() any {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// elapsed is the time since the loop was created, virtual or real.
+func (l *EventLoop) elapsed() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.elapsedLocked()
+}
+
+func (l *EventLoop) elapsedLocked() time.Duration {
+	if l.virtual {
+		return l.now
+	}
+	return time.Since(l.start)
+}
+
+// signal wakes the loop up if it waits for a timer.
+func (l *EventLoop) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (l *EventLoop) enqueue(job func()) {
+	l.mu.Lock()
+	l.jobs = append(l.jobs, job)
+	l.mu.Unlock()
+	l.signal()
+}
+
+// addTimer calls fn after delay, and then every interval when interval isn't 0.
+func (l *EventLoop) addTimer(delay, interval time.Duration, fn func()) int64 {
+	l.mu.Lock()
+	l.nextId++
+	timer := &loopTimer{id: l.nextId, at: l.elapsedLocked() + delay, interval: interval, fn: fn}
+	heap.Push(&l.timers, timer)
+	l.byId[timer.id] = timer
+	l.mu.Unlock()
+	// the loop may be waiting for a later timer
+	l.signal()
+	return timer.id
+}
+
+func (l *EventLoop) cancelTimer(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if timer, exist := l.byId[id]; exist {
+		timer.cancelled = true
+		delete(l.byId, id)
+	}
+}
+
+// step runs one job or one due timer, it returns false when there is nothing left.
+func (l *EventLoop) step() bool {
+	l.mu.Lock()
+	if len(l.jobs) > 0 {
+		job := l.jobs[0]
+		l.jobs = l.jobs[1:]
+		l.mu.Unlock()
+		job()
+		return true
+	}
+	for len(l.timers) > 0 && l.timers[0].cancelled {
+		heap.Pop(&l.timers)
+	}
+	if len(l.timers) == 0 {
+		l.mu.Unlock()
+		return false
+	}
+	timer := l.timers[0]
+	if l.virtual {
+		l.now = max(l.now, timer.at)
+	} else if wait := timer.at - l.elapsedLocked(); wait > 0 {
+		l.mu.Unlock()
+		select {
+		case <-time.After(wait):
+		case <-l.wake:
+		}
+		// a job could have been added or the timer cancelled meanwhile
+		return true
+	}
+	heap.Pop(&l.timers)
+	if timer.interval > 0 {
+		timer.at += timer.interval
+		heap.Push(&l.timers, timer)
+	} else {
+		delete(l.byId, timer.id)
+	}
+	l.mu.Unlock()
+	timer.fn()
+	return true
+}
+
+func (l *EventLoop) run() {
+	for l.step() {
+	}
+}
+
+func (l *EventLoop) suspend(co *coroutine, await Token) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.awaiting[co] = await
+}
+
+func (l *EventLoop) resume(co *coroutine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.awaiting, co)
+}
+
+// stalled returns the first await of the async functions that are
+// still suspended, once run has returned nothing can resume them.
+func (l *EventLoop) stalled() (await Token, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, token := range l.awaiting {
+		if !ok || token.Line < await.Line {
+			await, ok = token, true
+		}
+	}
+	return await, ok
+}
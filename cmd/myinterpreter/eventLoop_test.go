@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEarlierTimerWakesLoop(t *testing.T) {
+	loop := NewEventLoop()
+	var fired time.Duration
+	loop.addTimer(time.Second, 0, func() {})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		loop.addTimer(10*time.Millisecond, 0, func() { fired = loop.elapsed() })
+	}()
+	for fired == 0 && loop.step() {
+	}
+	if fired == 0 || fired > 500*time.Millisecond {
+		t.Fatalf("expected the earlier timer to fire first, it fired at %v", fired)
+	}
+}
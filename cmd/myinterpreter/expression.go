@@ -15,6 +15,15 @@ type visitor[T string | any] interface {
 	visitSuperExpr(*SuperExpr) T
 	visitArrayDeclExpr(*ArrayDeclExpr) T
 	visitSubscriptExpr(*SubscriptExpr) T
+	visitSubscriptSetExpr(*SubscriptSetExpr) T
+	visitTernaryExpr(*TernaryExpr) T
+	visitOptionalChainExpr(*OptionalChainExpr) T
+	visitDestructureAssignExpr(*DestructureAssignExpr) T
+	visitSliceExpr(*SliceExpr) T
+	visitSpreadExpr(*SpreadExpr) T
+	visitYieldExpr(*YieldExpr) T
+	visitSpawnExpr(*SpawnExpr) T
+	visitAwaitExpr(*AwaitExpr) T
 }
 
 type Expr interface {
@@ -118,14 +127,20 @@ func (var_ *VarExpr) accept(v visitor[any]) any {
 	return v.visitVarExpr(var_)
 }
 
+// operator is EQUAL for plain assignment
+// or one of compound assignment tokens (PLUS_EQUAL, ...)
 type AssignExpr struct {
-	name  Token
-	value Expr
+	name     Token
+	operator Token
+	value    Expr
+	// set by x++ and x--, the expression evaluates to the value before the update
+	postfix bool
 }
 
-func NewAssignExpr(name Token, value Expr) *AssignExpr {
+func NewAssignExpr(name Token, operator Token, value Expr) *AssignExpr {
 	a := new(AssignExpr)
 	a.name = name
+	a.operator = operator
 	a.value = value
 	return a
 }
@@ -160,15 +175,20 @@ func (logical *LogicalExpr) print(v visitor[string]) string {
 	return v.visitLogicalExpr(logical)
 }
 
+// CallExpr, names holds the name of every named argument
+// and nil for positional ones. optional is set for `callee?.()`
 type CallExpr struct {
 	caleeToken Token
 	callee     Expr
 	args       []Expr
+	names      []*Token
+	optional   bool
 }
 
-func NewCallExpr(caleeToken Token, callee Expr, args []Expr) *CallExpr {
+func NewCallExpr(caleeToken Token, callee Expr, args []Expr, names []*Token) *CallExpr {
 	c := new(CallExpr)
 	c.args = args
+	c.names = names
 	c.caleeToken = caleeToken
 	c.callee = callee
 	return c
@@ -182,9 +202,11 @@ func (call *CallExpr) print(v visitor[string]) string {
 	return v.visitCallExpr(call)
 }
 
+// optional is set for `object?.name`
 type GetExpr struct {
-	object Expr
-	name   Token
+	object   Expr
+	name     Token
+	optional bool
 }
 
 func NewGetExpr(object Expr, name Token) *GetExpr {
@@ -203,16 +225,20 @@ func (get *GetExpr) print(v visitor[string]) string {
 }
 
 type SetExpr struct {
-	object Expr
-	name   Token
-	value  Expr
+	object   Expr
+	name     Token
+	operator Token
+	value    Expr
+	// set by x++ and x--, the expression evaluates to the value before the update
+	postfix bool
 }
 
-func NewSetExpr(object Expr, name Token, value Expr) *SetExpr {
+func NewSetExpr(object Expr, name Token, operator Token, value Expr) *SetExpr {
 	return &SetExpr{
-		object: object,
-		name:   name,
-		value:  value,
+		object:   object,
+		name:     name,
+		operator: operator,
+		value:    value,
 	}
 }
 
@@ -278,11 +304,13 @@ func (arr *ArrayDeclExpr) print(v visitor[string]) string {
 	return v.visitArrayDeclExpr(arr)
 }
 
+// optional is set for `object?[index]`
 type SubscriptExpr struct {
 	objectToken Token
 	object      Expr
 	indexToken  Token
 	index       Expr
+	optional    bool
 }
 
 func NewSubscriptExpr(object, index Expr, objectT, indexT Token) *SubscriptExpr {
@@ -301,3 +329,212 @@ func (sub *SubscriptExpr) accept(v visitor[any]) any {
 func (sub *SubscriptExpr) print(v visitor[string]) string {
 	return v.visitSubscriptExpr(sub)
 }
+
+type SubscriptSetExpr struct {
+	objectToken Token
+	object      Expr
+	indexToken  Token
+	index       Expr
+	operator    Token
+	value       Expr
+	// set by x++ and x--, the expression evaluates to the value before the update
+	postfix bool
+}
+
+func NewSubscriptSetExpr(sub *SubscriptExpr, operator Token, value Expr) *SubscriptSetExpr {
+	return &SubscriptSetExpr{
+		object:      sub.object,
+		index:       sub.index,
+		objectToken: sub.objectToken,
+		indexToken:  sub.indexToken,
+		operator:    operator,
+		value:       value,
+	}
+}
+
+func (sub *SubscriptSetExpr) accept(v visitor[any]) any {
+	return v.visitSubscriptSetExpr(sub)
+}
+
+func (sub *SubscriptSetExpr) print(v visitor[string]) string {
+	return v.visitSubscriptSetExpr(sub)
+}
+
+type TernaryExpr struct {
+	question   Token
+	condition  Expr
+	thenBranch Expr
+	elseBranch Expr
+}
+
+func NewTernaryExpr(question Token, condition, thenBranch, elseBranch Expr) *TernaryExpr {
+	return &TernaryExpr{
+		question:   question,
+		condition:  condition,
+		thenBranch: thenBranch,
+		elseBranch: elseBranch,
+	}
+}
+
+func (ternary *TernaryExpr) accept(v visitor[any]) any {
+	return v.visitTernaryExpr(ternary)
+}
+
+func (ternary *TernaryExpr) print(v visitor[string]) string {
+	return v.visitTernaryExpr(ternary)
+}
+
+// OptionalChainExpr wraps a whole chain of calls, gets and subscripts
+// containing at least one optional link. When an optional link finds nil
+// the rest of the chain is skipped and the chain evaluates to nil.
+type OptionalChainExpr struct {
+	expr Expr
+}
+
+func NewOptionalChainExpr(expr Expr) *OptionalChainExpr {
+	return &OptionalChainExpr{expr: expr}
+}
+
+func (chain *OptionalChainExpr) accept(v visitor[any]) any {
+	return v.visitOptionalChainExpr(chain)
+}
+
+func (chain *OptionalChainExpr) print(v visitor[string]) string {
+	return v.visitOptionalChainExpr(chain)
+}
+
+// DestructureAssignExpr is `[a, obj.field, arr[0]] = value`,
+// every target is a VarExpr, GetExpr or SubscriptExpr.
+type DestructureAssignExpr struct {
+	bracket Token
+	targets []Expr
+	value   Expr
+}
+
+func NewDestructureAssignExpr(bracket Token, targets []Expr, value Expr) *DestructureAssignExpr {
+	return &DestructureAssignExpr{
+		bracket: bracket,
+		targets: targets,
+		value:   value,
+	}
+}
+
+func (d *DestructureAssignExpr) accept(v visitor[any]) any {
+	return v.visitDestructureAssignExpr(d)
+}
+
+func (d *DestructureAssignExpr) print(v visitor[string]) string {
+	return v.visitDestructureAssignExpr(d)
+}
+
+// SliceExpr is `object[start:end:step]`, missing parts are nil
+type SliceExpr struct {
+	bracket  Token
+	object   Expr
+	start    Expr
+	end      Expr
+	step     Expr
+	optional bool
+}
+
+func NewSliceExpr(object Expr, bracket Token, start, end, step Expr) *SliceExpr {
+	return &SliceExpr{
+		bracket: bracket,
+		object:  object,
+		start:   start,
+		end:     end,
+		step:    step,
+	}
+}
+
+func (slice *SliceExpr) accept(v visitor[any]) any {
+	return v.visitSliceExpr(slice)
+}
+
+func (slice *SliceExpr) print(v visitor[string]) string {
+	return v.visitSliceExpr(slice)
+}
+
+// SpreadExpr is `...value` inside call arguments and array literals.
+type SpreadExpr struct {
+	ellipsis Token
+	value    Expr
+}
+
+func NewSpreadExpr(ellipsis Token, value Expr) *SpreadExpr {
+	return &SpreadExpr{
+		ellipsis: ellipsis,
+		value:    value,
+	}
+}
+
+func (spread *SpreadExpr) accept(v visitor[any]) any {
+	return v.visitSpreadExpr(spread)
+}
+
+func (spread *SpreadExpr) print(v visitor[string]) string {
+	return v.visitSpreadExpr(spread)
+}
+
+// YieldExpr is `yield value`, value is nil for a bare yield.
+type YieldExpr struct {
+	keyword Token
+	value   Expr
+}
+
+func NewYieldExpr(keyword Token, value Expr) *YieldExpr {
+	return &YieldExpr{
+		keyword: keyword,
+		value:   value,
+	}
+}
+
+func (y *YieldExpr) accept(v visitor[any]) any {
+	return v.visitYieldExpr(y)
+}
+
+func (y *YieldExpr) print(v visitor[string]) string {
+	return v.visitYieldExpr(y)
+}
+
+// SpawnExpr is `spawn f(args)`, the call runs in a new task.
+type SpawnExpr struct {
+	keyword Token
+	call    *CallExpr
+}
+
+func NewSpawnExpr(keyword Token, call *CallExpr) *SpawnExpr {
+	return &SpawnExpr{
+		keyword: keyword,
+		call:    call,
+	}
+}
+
+func (s *SpawnExpr) accept(v visitor[any]) any {
+	return v.visitSpawnExpr(s)
+}
+
+func (s *SpawnExpr) print(v visitor[string]) string {
+	return v.visitSpawnExpr(s)
+}
+
+// AwaitExpr is `await value`, it waits for value when it is a promise.
+type AwaitExpr struct {
+	keyword Token
+	value   Expr
+}
+
+func NewAwaitExpr(keyword Token, value Expr) *AwaitExpr {
+	return &AwaitExpr{
+		keyword: keyword,
+		value:   value,
+	}
+}
+
+func (a *AwaitExpr) accept(v visitor[any]) any {
+	return v.visitAwaitExpr(a)
+}
+
+func (a *AwaitExpr) print(v visitor[string]) string {
+	return v.visitAwaitExpr(a)
+}
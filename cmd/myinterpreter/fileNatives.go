@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LoxFile is a handle returned by open(path, mode)
+type LoxFile struct {
+	path   string
+	file   *os.File
+	reader *bufio.Reader
+	closed bool
+}
+
+func NewLoxFile(path string, file *os.File) *LoxFile {
+	return &LoxFile{
+		path:   path,
+		file:   file,
+		reader: bufio.NewReader(file),
+	}
+}
+
+func (f *LoxFile) String() string {
+	return fmt.Sprintf("<file %v>", f.path)
+}
+
+func (i Interpreter) fileMethod(receiver *LoxFile, name Token) any {
+	method, exist := fileMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on file", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+var openModes = map[string]int{
+	"r": os.O_RDONLY,
+	"w": os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"a": os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+}
+
+func splitLines(content string) *LoxArray {
+	lines := make([]any, 0)
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return NewLoxArray(lines)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+	return NewLoxArray(lines)
+}
+
+// Failures are returned as LoxError, wrong argument types are runtime errors.
+var fileNatives = map[string]*NativeFunction{
+	"readFile": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		content, err := os.ReadFile(i.stringArg(args, 0))
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		return string(content)
+	}),
+	"readLines": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		content, err := os.ReadFile(i.stringArg(args, 0))
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		return splitLines(string(content))
+	}),
+	"writeFile": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		if err := os.WriteFile(i.stringArg(args, 0), []byte(i.stringArg(args, 1)), 0644); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	"appendFile": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		file, err := os.OpenFile(i.stringArg(args, 0), openModes["a"], 0644)
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		defer file.Close()
+		if _, err := file.WriteString(i.stringArg(args, 1)); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	"exists": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		_, err := os.Stat(i.stringArg(args, 0))
+		return err == nil
+	}),
+	"listDir": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		entries, err := os.ReadDir(i.stringArg(args, 0))
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		names := make([]string, len(entries))
+		for idx, entry := range entries {
+			names[idx] = entry.Name()
+		}
+		sort.Strings(names)
+		res := make([]any, len(names))
+		for idx, name := range names {
+			res[idx] = name
+		}
+		return NewLoxArray(res)
+	}),
+	"mkdir": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		if err := os.MkdirAll(i.stringArg(args, 0), 0755); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	"remove": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		if err := os.Remove(i.stringArg(args, 0)); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	"rename": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		if err := os.Rename(i.stringArg(args, 0), i.stringArg(args, 1)); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	// open(path) reads, open(path, "w") truncates, open(path, "a") appends
+	"open": NewNativeFunction(1, 2, func(i Interpreter, args []any) any {
+		path := i.stringArg(args, 0)
+		mode := "r"
+		if len(args) == 2 {
+			mode = i.stringArg(args, 1)
+		}
+		flag, exist := openModes[mode]
+		if !exist {
+			i.error(i.callToken, fmt.Sprintf("Unknown file mode '%v', expect 'r', 'w' or 'a'", mode))
+		}
+		file, err := os.OpenFile(path, flag, 0644)
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		return NewLoxFile(path, file)
+	}),
+}
+
+var fileMethods = map[string]nativeMethod{
+	// read returns the rest of the file
+	"read": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		file := m.receiver.(*LoxFile)
+		if file.closed {
+			return NewLoxError("file is closed")
+		}
+		content, err := io.ReadAll(file.reader)
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		return string(content)
+	}},
+	// readLine returns nil at the end of the file
+	"readLine": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		file := m.receiver.(*LoxFile)
+		if file.closed {
+			return NewLoxError("file is closed")
+		}
+		line, err := file.reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return NewLoxError(err.Error())
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	}},
+	"write": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		file := m.receiver.(*LoxFile)
+		if file.closed {
+			return NewLoxError("file is closed")
+		}
+		if _, err := file.file.WriteString(m.stringArg(i, args, 0)); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}},
+	"close": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		file := m.receiver.(*LoxFile)
+		if file.closed {
+			return nil
+		}
+		file.closed = true
+		if err := file.file.Close(); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}},
+}
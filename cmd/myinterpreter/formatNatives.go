@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatSpec is a parsed %[flags][width][.precision]verb directive.
+type formatSpec struct {
+	flags     string
+	width     int
+	precision int
+	verb      rune
+}
+
+func (spec formatSpec) goFormat() string {
+	format := "%" + spec.flags
+	if spec.width > 0 {
+		format += fmt.Sprint(spec.width)
+	}
+	if spec.precision >= 0 {
+		format += fmt.Sprintf(".%v", spec.precision)
+	}
+	return format + string(spec.verb)
+}
+
+// pad applies width and alignment to an already formatted value.
+func (spec formatSpec) pad(s string) string {
+	missing := spec.width - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s
+	}
+	if strings.Contains(spec.flags, "-") {
+		return s + strings.Repeat(" ", missing)
+	}
+	if strings.Contains(spec.flags, "0") {
+		sign := ""
+		if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+			sign, s = s[:1], s[1:]
+		}
+		return sign + strings.Repeat("0", missing) + s
+	}
+	return strings.Repeat(" ", missing) + s
+}
+
+// parseFormatSpec parses a directive starting right after '%',
+// it returns the spec and the number of runes consumed.
+func parseFormatSpec(directive []rune) (formatSpec, int, bool) {
+	spec := formatSpec{precision: -1}
+	pos := 0
+	for pos < len(directive) && strings.ContainsRune("-+0 ", directive[pos]) {
+		spec.flags += string(directive[pos])
+		pos++
+	}
+	for pos < len(directive) && isDigit(directive[pos]) {
+		spec.width = spec.width*10 + int(directive[pos]-'0')
+		pos++
+	}
+	if pos < len(directive) && directive[pos] == '.' {
+		pos++
+		spec.precision = 0
+		for pos < len(directive) && isDigit(directive[pos]) {
+			spec.precision = spec.precision*10 + int(directive[pos]-'0')
+			pos++
+		}
+	}
+	if pos == len(directive) {
+		return spec, pos, false
+	}
+	spec.verb = directive[pos]
+	return spec, pos + 1, true
+}
+
+func (i Interpreter) formatArg(spec formatSpec, arg any) string {
+	switch spec.verb {
+	case 'd':
+		if !isInteger(arg) {
+			i.error(i.callToken, "Format %d expects an integer")
+		}
+		return fmt.Sprintf(spec.goFormat(), arg)
+	case 'x', 'X':
+		if !isInteger(arg) {
+			if _, ok := arg.(string); !ok {
+				i.error(i.callToken, fmt.Sprintf("Format %%%c expects an integer or a string", spec.verb))
+			}
+		}
+		return fmt.Sprintf(spec.goFormat(), arg)
+	case 'f':
+		if !isNumber(arg) {
+			i.error(i.callToken, "Format %f expects a number")
+		}
+		precision := spec.precision
+		if precision < 0 {
+			precision = 6
+		}
+		// decimals are rounded exactly instead of going through float
+		if d, ok := arg.(*LoxDecimal); ok {
+			rounded := d.Round(precision, i.decimalContext.Load().rounding)
+			s := NewLoxDecimal(rounded.rescale(precision), precision).String()
+			if strings.Contains(spec.flags, "+") && rounded.unscaled.Sign() >= 0 {
+				s = "+" + s
+			}
+			return spec.pad(s)
+		}
+		spec.precision = precision
+		return fmt.Sprintf(spec.goFormat(), toFloat(arg))
+	case 's':
+		s, ok := arg.(string)
+		if !ok {
+			i.error(i.callToken, "Format %s expects a string, use %v for other values")
+		}
+		return fmt.Sprintf(spec.goFormat(), s)
+	case 'v':
+		s := i.stringify(arg)
+		if spec.precision >= 0 && utf8.RuneCountInString(s) > spec.precision {
+			s = string([]rune(s)[:spec.precision])
+		}
+		return spec.pad(s)
+	}
+	i.error(i.callToken, fmt.Sprintf("Unknown format verb '%%%c'", spec.verb))
+	return ""
+}
+
+// format implements format(fmtString, ...args), see formatArg for supported verbs.
+func (i Interpreter) format(args []any) string {
+	directives := []rune(i.stringArg(args, 0))
+	args = args[1:]
+	var res strings.Builder
+	argIdx := 0
+	for pos := 0; pos < len(directives); pos++ {
+		if directives[pos] != '%' {
+			res.WriteRune(directives[pos])
+			continue
+		}
+		if pos+1 < len(directives) && directives[pos+1] == '%' {
+			res.WriteRune('%')
+			pos++
+			continue
+		}
+		spec, length, ok := parseFormatSpec(directives[pos+1:])
+		if !ok {
+			i.error(i.callToken, "Incomplete format directive at the end of format string")
+		}
+		pos += length
+		if argIdx >= len(args) {
+			i.error(i.callToken, fmt.Sprintf("Not enough arguments for format string, got %v", len(args)))
+		}
+		res.WriteString(i.formatArg(spec, args[argIdx]))
+		argIdx++
+	}
+	if argIdx < len(args) {
+		i.error(i.callToken, fmt.Sprintf("Too many arguments for format string, expected %v but got %v", argIdx, len(args)))
+	}
+	return res.String()
+}
+
+var formatNatives = map[string]*NativeFunction{
+	"format": NewNativeFunction(1, -1, func(i Interpreter, args []any) any {
+		return i.format(args)
+	}),
+	// printf doesn't add a line break
+	"printf": NewNativeFunction(1, -1, func(i Interpreter, args []any) any {
+		fmt.Print(i.format(args))
+		return nil
+	}),
+}
@@ -2,8 +2,9 @@ package main
 
 import (
 	"fmt"
-	"os"
-	"reflect"
+	"math"
+	"math/big"
+	"sync/atomic"
 )
 
 type Interpreter struct {
@@ -11,6 +12,16 @@ type Interpreter struct {
 	globals *State
 	locals  map[Expr]int
 	parser  *Parser
+	// shared by all tasks, decimalContext() replaces it as a whole
+	decimalContext *atomic.Pointer[DecimalContext]
+	// token of the call being executed, natives report errors at it
+	callToken Token
+	// generator whose body is being executed, yield hands values to it
+	generator *generatorState
+	loop      *EventLoop
+	// async function being executed, await suspends it
+	coroutine *coroutine
+	tasks     *taskGroup
 }
 
 func NewInterpreter(parser *Parser) *Interpreter {
@@ -20,6 +31,10 @@ func NewInterpreter(parser *Parser) *Interpreter {
 	i.globals = i.state
 	i.locals = make(map[Expr]int, 0)
 	i.parser = parser
+	i.decimalContext = new(atomic.Pointer[DecimalContext])
+	i.decimalContext.Store(NewDecimalContext())
+	i.loop = NewEventLoop()
+	i.tasks = newTaskGroup()
 	return i
 }
 
@@ -29,6 +44,33 @@ func (i *Interpreter) addBuiltins() {
 	i.state.define("str", &Str{})
 	i.state.define("len", &Len{})
 	i.state.define("println", &PrintLine{})
+	i.state.define("bigint", &BigInt{})
+	i.state.define("decimal", &Decimal{})
+	i.state.define("decimalContext", &SetDecimalContext{})
+	i.state.define("math", NewMathModule())
+	i.state.define("isError", &IsError{})
+	i.state.define("regex", regexNative)
+	for name, native := range fileNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range stdinNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range systemNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range jsonNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range formatNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range concurrencyNatives {
+		i.state.define(name, native)
+	}
+	for name, native := range eventLoopNatives {
+		i.state.define(name, native)
+	}
 }
 
 func (i Interpreter) visitVarExpr(expr *VarExpr) any {
@@ -50,12 +92,30 @@ func (i Interpreter) visitUnaryExpr(expr *UnaryExpr) any {
 	case BANG:
 		return !booleanCast(right)
 	case MINUS:
-		switch right.(type) {
+		switch right := right.(type) {
+		case int64:
+			if right == math.MinInt64 {
+				return new(big.Int).Neg(big.NewInt(right))
+			}
+			return -right
+		case *big.Int:
+			return new(big.Int).Neg(right)
+		case *LoxDecimal:
+			return right.Neg()
 		case float64:
-			return -(right.(float64))
+			return -right
 		default:
 			i.loxRuntimePanicBinNumeric()
 		}
+	case TILDE:
+		switch right := right.(type) {
+		case int64:
+			return ^right
+		case *big.Int:
+			return new(big.Int).Not(right)
+		default:
+			i.error(expr.operator, "Operand must be an integer")
+		}
 	}
 
 	return nil
@@ -64,95 +124,78 @@ func (i Interpreter) visitUnaryExpr(expr *UnaryExpr) any {
 func (i Interpreter) visitBinaryExpr(expr *BinaryExpr) any {
 	left := i.evaluate(expr.left)
 	right := i.evaluate(expr.right)
+	return i.binary(expr.operator, left, right)
+}
 
-	switch expr.operator.Token {
-	case STAR:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) * right.(float64)
-		}
-		i.loxRuntimePanicBinNumeric()
-	case SLASH:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) / right.(float64)
-		}
-		i.loxRuntimePanicBinNumeric()
-	case PERCENT:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			leftIntegral := int64(left.(float64))
-			rightIntegral := int64(right.(float64))
-			if float64(leftIntegral) > left.(float64) || float64(rightIntegral) > right.(float64) {
-				i.error(expr.operator, "Expect integral numbers")
-			}
-			return float64(leftIntegral % rightIntegral)
-		}
-		i.loxRuntimePanicBinNumeric()
+func (i Interpreter) binary(operator Token, left, right any) any {
+	switch operator.Token {
+	case STAR, SLASH, PERCENT, MINUS, STAR_STAR, TILDE_SLASH:
+		return i.arithmetic(operator, left, right)
+	case AMPERSAND, PIPE, CARET, LESS_LESS, GREATER_GREATER:
+		return i.bitwise(operator, left, right)
 	case PLUS:
-		left_type := reflect.TypeOf(left)
-		right_type := reflect.TypeOf(right)
-		if left_type.Kind() == reflect.String && right_type.Kind() == reflect.String {
-			return left.(string) + right.(string)
-		} else if left_type.Kind() == reflect.Float64 && right_type.Kind() == reflect.Float64 {
-			return left.(float64) + right.(float64)
+		left_str, left_ok := left.(string)
+		right_str, right_ok := right.(string)
+		if left_ok && right_ok {
+			return left_str + right_str
+		} else if isNumber(left) && isNumber(right) {
+			return i.arithmetic(operator, left, right)
 		}
-		i.error(expr.operator, "Operands must be two numbers or two strings")
-	case MINUS:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) - right.(float64)
-		}
-		i.loxRuntimePanicBinNumeric()
-	case GREATER:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) > right.(float64)
-		}
-		i.loxRuntimePanicBinNumeric()
-	case GREATER_EQUAL:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) >= right.(float64)
+		i.error(operator, "Operands must be two numbers or two strings")
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		if !isNumber(left) || !isNumber(right) {
+			i.loxRuntimePanicBinNumeric()
 		}
-		i.loxRuntimePanicBinNumeric()
-	case LESS:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) < right.(float64)
+		cmp, ok := compareNumbers(left, right)
+		if !ok {
+			return false
 		}
-		i.loxRuntimePanicBinNumeric()
-	case LESS_EQUAL:
-		if reflect.TypeOf(left).Kind() == reflect.Float64 && reflect.TypeOf(right).Kind() == reflect.Float64 {
-			return left.(float64) <= right.(float64)
+		switch operator.Token {
+		case GREATER:
+			return cmp > 0
+		case GREATER_EQUAL:
+			return cmp >= 0
+		case LESS:
+			return cmp < 0
+		case LESS_EQUAL:
+			return cmp <= 0
 		}
-		i.loxRuntimePanicBinNumeric()
 	case EQUAL_EQUAL:
-		left_type := reflect.TypeOf(left)
-		right_type := reflect.TypeOf(right)
-		if left_type.Kind() != right_type.Kind() {
-			return false
-		} else if left_type.Kind() == reflect.String {
-			return left.(string) == right.(string)
-		} else if left_type.Kind() == reflect.Float64 {
-			return left.(float64) == right.(float64)
-		} else if left_type.Kind() == reflect.Bool {
-			return left.(bool) == right.(bool)
-		}
-
+		return isEqual(left, right)
 	case BANG_EQUAL:
-		left_type := reflect.TypeOf(left)
-		right_type := reflect.TypeOf(right)
-		if left_type.Kind() != right_type.Kind() {
-			return true
-		} else if left_type.Kind() == reflect.String {
-			return left.(string) != right.(string)
-		} else if left_type.Kind() == reflect.Float64 {
-			return left.(float64) != right.(float64)
-		} else if left_type.Kind() == reflect.Bool {
-			return left.(bool) != right.(bool)
-		}
+		return !isEqual(left, right)
 	}
 
 	return nil
 }
 
+var compoundOperators = map[TokenType]TokenType{
+	PLUS_EQUAL:    PLUS,
+	MINUS_EQUAL:   MINUS,
+	STAR_EQUAL:    STAR,
+	SLASH_EQUAL:   SLASH,
+	PERCENT_EQUAL: PERCENT,
+}
+
+// assignedValue computes the value stored by an assignment,
+// for compound operators it's `current <op> value`.
+// current is called only for compound assignments.
+func (i Interpreter) assignedValue(operator Token, current func() any, value any) any {
+	binaryOperator, ok := compoundOperators[operator.Token]
+	if !ok {
+		return value
+	}
+	lexeme := operator.Lexeme[:len(operator.Lexeme)-1]
+	return i.binary(*NewToken(lexeme, binaryOperator, nil, operator.Line), current(), value)
+}
+
 func (i Interpreter) visitLogicalExpr(expr *LogicalExpr) any {
 	left := i.evaluate(expr.left)
-	if expr.operator.Token == OR {
+	if expr.operator.Token == QUESTION_QUESTION {
+		if left != nil {
+			return left
+		}
+	} else if expr.operator.Token == OR {
 		if booleanCast(left) == true {
 			return left
 		}
@@ -165,8 +208,39 @@ func (i Interpreter) visitLogicalExpr(expr *LogicalExpr) any {
 	return right
 }
 
+func (i Interpreter) visitTernaryExpr(expr *TernaryExpr) any {
+	if booleanCast(i.evaluate(expr.condition)) {
+		return i.evaluate(expr.thenBranch)
+	}
+	return i.evaluate(expr.elseBranch)
+}
+
+// optionalChainNil unwinds evaluation from a nil optional link up to the chain
+type optionalChainNil struct{}
+
+func (i Interpreter) visitOptionalChainExpr(expr *OptionalChainExpr) (result any) {
+	defer func() {
+		if err := recover(); err != nil {
+			if _, ok := err.(optionalChainNil); !ok {
+				panic(err)
+			}
+			result = nil
+		}
+	}()
+	return i.evaluate(expr.expr)
+}
+
 func (i Interpreter) visitCallExpr(expr *CallExpr) any {
+	callee, arguments := i.evaluateCall(expr)
+	return i.callValue(expr.caleeToken, callee, arguments)
+}
+
+// evaluateCall evaluates callee and arguments of a call without calling it.
+func (i Interpreter) evaluateCall(expr *CallExpr) (any, []any) {
 	callee := i.evaluate(expr.callee)
+	if expr.optional && callee == nil {
+		panic(optionalChainNil{})
+	}
 	switch callee.(type) {
 	case LoxCallable:
 		goto FINE
@@ -175,54 +249,283 @@ func (i Interpreter) visitCallExpr(expr *CallExpr) any {
 	}
 FINE:
 	arguments := make([]any, 0)
-	for _, arg := range expr.args {
-		arguments = append(arguments, i.evaluate(arg))
+	for idx, arg := range expr.args {
+		if spread, ok := arg.(*SpreadExpr); ok {
+			arguments = append(arguments, i.iterate(spread.ellipsis, i.evaluate(spread.value))...)
+			continue
+		}
+		value := i.evaluate(arg)
+		if name := expr.names[idx]; name != nil {
+			arguments = i.bindNamedArgument(callee, arguments, *name, value)
+		} else {
+			arguments = append(arguments, value)
+		}
 	}
-	var function LoxCallable
-	function = callee.(LoxCallable)
-	if function.arity() != len(arguments) {
-		i.error(expr.caleeToken, fmt.Sprintf("expected %v arguments but got %v\n", function.arity(), len(arguments)))
+	return callee, arguments
+}
+
+// bindNamedArgument puts value to the position of the parameter called name,
+// skipped parameters are filled with missingArgument.
+func (i Interpreter) bindNamedArgument(callee any, arguments []any, name Token, value any) []any {
+	var declaration *Function = nil
+	if function, ok := callee.(parametrized); ok {
+		declaration = function.parameters()
+	}
+	if declaration == nil {
+		i.error(name, "Named arguments can only be passed to lox functions")
+	}
+	for idx, param := range declaration.arguments {
+		if param.Lexeme != name.Lexeme {
+			continue
+		}
+		for len(arguments) <= idx {
+			arguments = append(arguments, missingArgument{})
+		}
+		if _, missing := arguments[idx].(missingArgument); !missing {
+			i.error(name, fmt.Sprintf("Argument '%v' is passed more than once", name.Lexeme))
+		}
+		arguments[idx] = value
+		return arguments
 	}
+	i.error(name, fmt.Sprintf("Unknown parameter '%v'", name.Lexeme))
+	return nil
+}
+
+// callValue checks arity and calls a lox value with evaluated arguments,
+// natives use it to call lox callables passed to them.
+func (i Interpreter) callValue(token Token, callee any, arguments []any) any {
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		i.error(token, "Can only call functions and classes")
+	}
+	minArity, maxArity := function.arity(), function.arity()
+	if variadic, ok := function.(variadicCallable); ok {
+		minArity, maxArity = variadic.arityRange()
+	}
+	if len(arguments) < minArity || (maxArity >= 0 && len(arguments) > maxArity) {
+		expected := fmt.Sprint(minArity)
+		if maxArity < 0 {
+			expected = fmt.Sprintf("at least %v", minArity)
+		} else if minArity != maxArity {
+			expected = fmt.Sprintf("%v to %v", minArity, maxArity)
+		}
+		i.error(token, fmt.Sprintf("expected %v arguments but got %v", expected, len(arguments)))
+	}
+	i.callToken = token
 	return function.call(i, arguments)
 }
 
 func (i Interpreter) visitArrayDeclExpr(expr *ArrayDeclExpr) any {
-	eval_elements := make([]any, len(expr.elements))
-	for idx, element := range expr.elements {
-		eval_elements[idx] = i.evaluate(element)
+	eval_elements := make([]any, 0, len(expr.elements))
+	for _, element := range expr.elements {
+		if spread, ok := element.(*SpreadExpr); ok {
+			eval_elements = append(eval_elements, i.iterate(spread.ellipsis, i.evaluate(spread.value))...)
+		} else {
+			eval_elements = append(eval_elements, i.evaluate(element))
+		}
 	}
-	return eval_elements
+	return NewLoxArray(eval_elements)
+}
+
+func (i Interpreter) visitSpreadExpr(expr *SpreadExpr) any {
+	i.error(expr.ellipsis, "Spread is only allowed in arguments and array literals")
+	return nil
 }
 
 func (i Interpreter) visitSubscriptExpr(expr *SubscriptExpr) any {
 	array := i.evaluate(expr.object)
+	if expr.optional && array == nil {
+		panic(optionalChainNil{})
+	}
 	index := i.evaluate(expr.index)
 	switch array := array.(type) {
-	case []any:
-		switch index := index.(type) {
-		case float64:
-			intIndex := int64(index)
-			if float64(intIndex) < index {
-				i.error(expr.indexToken, "Expected integral number")
-			}
-			if intIndex >= int64(len(array)) {
-				i.error(expr.indexToken, "Out of range")
-			}
-			return array[intIndex]
-		default:
-			i.error(expr.indexToken, "Expect number")
+	case *LoxArray:
+		var element any
+		array.read(func() {
+			element = array.elements[i.arrayIndex(array, index, expr.indexToken)]
+		})
+		return element
+	case string:
+		// strings are indexed by code points, not bytes
+		runes := []rune(array)
+		return string(runes[i.sequenceIndex(int64(len(runes)), index, expr.indexToken)])
+	default:
+		i.error(expr.objectToken, "Only arrays and strings can be subscripted")
+	}
+	panic("unreachable")
+}
+
+func (i Interpreter) visitSubscriptSetExpr(expr *SubscriptSetExpr) any {
+	array := i.evaluate(expr.object)
+	index := i.evaluate(expr.index)
+	switch array := array.(type) {
+	case *LoxArray:
+		value := i.evaluate(expr.value)
+		var previous any
+		// the lock is held while combining, so `arr[0] += 1` is atomic across tasks
+		array.update(func() {
+			idx := i.arrayIndex(array, index, expr.indexToken)
+			previous = array.elements[idx]
+			value = i.assignedValue(expr.operator, func() any { return previous }, value)
+			array.elements[idx] = value
+		})
+		if expr.postfix {
+			return previous
 		}
+		return value
+	case string:
+		i.error(expr.objectToken, "Strings are immutable")
 	default:
 		i.error(expr.objectToken, "Only arrays can be subscripted")
 	}
 	panic("unreachable")
 }
 
+// arrayIndex validates index, negative indices count from the end,
+// the caller holds the array lock.
+func (i Interpreter) arrayIndex(array *LoxArray, index any, indexToken Token) int64 {
+	return i.sequenceIndex(int64(len(array.elements)), index, indexToken)
+}
+
+func (i Interpreter) sequenceIndex(length int64, index any, indexToken Token) int64 {
+	intIndex := i.integralIndex(index, indexToken)
+	if intIndex < 0 {
+		if intIndex+length < 0 {
+			i.error(indexToken, fmt.Sprintf("Index %v out of range for length %v", formatNumber(index), length))
+		}
+		return intIndex + length
+	}
+	if intIndex >= length {
+		i.error(indexToken, fmt.Sprintf("Index %v out of range for length %v", formatNumber(index), length))
+	}
+	return intIndex
+}
+
+// integralIndex converts an index to int64, bigints don't fit
+// and saturate, so they are out of range of any sequence.
+func (i Interpreter) integralIndex(index any, indexToken Token) int64 {
+	switch index := index.(type) {
+	case int64:
+		return index
+	case *big.Int:
+		if index.Sign() < 0 {
+			return -math.MaxInt64
+		}
+		return math.MaxInt64
+	case float64:
+		intIndex := int64(index)
+		if float64(intIndex) != index {
+			i.error(indexToken, "Expected integral number")
+		}
+		return intIndex
+	default:
+		i.error(indexToken, "Expect number")
+	}
+	panic("unreachable")
+}
+
+func (i Interpreter) visitSliceExpr(expr *SliceExpr) any {
+	object := i.evaluate(expr.object)
+	if expr.optional && object == nil {
+		panic(optionalChainNil{})
+	}
+	bound := func(e Expr) any {
+		if e == nil {
+			return nil
+		}
+		return i.evaluate(e)
+	}
+	start, end, step := bound(expr.start), bound(expr.end), bound(expr.step)
+
+	switch object := object.(type) {
+	case *LoxArray:
+		return object.slice(func(length int64) []int64 {
+			return i.sliceIndices(length, start, end, step, expr.bracket)
+		})
+	case string:
+		runes := []rune(object)
+		indices := i.sliceIndices(int64(len(runes)), start, end, step, expr.bracket)
+		res := make([]rune, len(indices))
+		for idx, from := range indices {
+			res[idx] = runes[from]
+		}
+		return string(res)
+	default:
+		i.error(expr.bracket, "Only arrays and strings can be sliced")
+	}
+	panic("unreachable")
+}
+
+// sliceIndices follows python rules: missing bounds default to the whole
+// sequence, negative bounds count from the end and out of range bounds are clamped.
+func (i Interpreter) sliceIndices(length int64, start, end, step any, bracket Token) []int64 {
+	stepValue := int64(1)
+	if step != nil {
+		stepValue = i.integralIndex(step, bracket)
+	}
+	if stepValue == 0 {
+		i.error(bracket, "Slice step can't be zero")
+	}
+	// a step longer than the sequence takes at most one element
+	stepValue = max(-length-1, min(stepValue, length+1))
+	lower, upper := int64(0), length
+	if stepValue < 0 {
+		lower, upper = -1, length-1
+	}
+	clamp := func(bound any, byDefault int64) int64 {
+		if bound == nil {
+			return byDefault
+		}
+		value := i.integralIndex(bound, bracket)
+		if value < 0 {
+			value += length
+		}
+		return max(lower, min(value, upper))
+	}
+
+	var from, to int64
+	if stepValue > 0 {
+		from, to = clamp(start, lower), clamp(end, upper)
+	} else {
+		from, to = clamp(start, upper), clamp(end, lower)
+	}
+	indices := make([]int64, 0)
+	for idx := from; (stepValue > 0 && idx < to) || (stepValue < 0 && idx > to); idx += stepValue {
+		indices = append(indices, idx)
+	}
+	return indices
+}
+
 func (i Interpreter) visitGetExpr(expr *GetExpr) any {
 	object := i.evaluate(expr.object)
+	if expr.optional && object == nil {
+		panic(optionalChainNil{})
+	}
 	switch object.(type) {
 	case *LoxInstance:
-		return object.(*LoxInstance).Get(expr.name)
+		return object.(*LoxInstance).Get(i, expr.name)
+	case string:
+		return i.stringMethod(object.(string), expr.name)
+	case *LoxArray:
+		return i.arrayMethod(object.(*LoxArray), expr.name)
+	case *LoxModule:
+		return object.(*LoxModule).Get(i, expr.name)
+	case *LoxFile:
+		return i.fileMethod(object.(*LoxFile), expr.name)
+	case *LoxError:
+		return object.(*LoxError).Get(i, expr.name)
+	case *LoxRegex:
+		return i.regexMethod(object.(*LoxRegex), expr.name)
+	case *LoxGenerator:
+		return i.generatorMethod(object.(*LoxGenerator), expr.name)
+	case *LoxTask:
+		return i.taskMethod(object.(*LoxTask), expr.name)
+	case *LoxChannel:
+		return i.channelMethod(object.(*LoxChannel), expr.name)
+	case *LoxMutex:
+		return i.mutexMethod(object.(*LoxMutex), expr.name)
+	case *LoxPromise:
+		return i.promiseMethod(object.(*LoxPromise), expr.name)
 	default:
 		i.error(expr.name, "Only instance have properties")
 	}
@@ -231,14 +534,21 @@ func (i Interpreter) visitGetExpr(expr *GetExpr) any {
 
 func (i Interpreter) visitSetExpr(expr *SetExpr) any {
 	object := i.evaluate(expr.object)
-	var exprRes any
+	var exprRes, previous any
 	switch object.(type) {
 	case *LoxInstance:
-		exprRes = i.evaluate(expr.value)
-		object.(*LoxInstance).Set(expr.name, exprRes)
+		instance := object.(*LoxInstance)
+		exprRes = i.assignedValue(expr.operator, func() any {
+			previous = instance.Get(i, expr.name)
+			return previous
+		}, i.evaluate(expr.value))
+		instance.Set(expr.name, exprRes)
 	default:
 		i.error(expr.name, "Only instance have fields")
 	}
+	if expr.postfix {
+		return previous
+	}
 	return exprRes
 }
 
@@ -250,7 +560,7 @@ func (i Interpreter) visitSuperExpr(expr *SuperExpr) any {
 	if method == nil {
 		i.error(expr.keyword, fmt.Sprintf("Undefined property %v'", expr.method.Lexeme))
 	}
-	return method.bind(instance)
+	return i.bindMethod(instance, method)
 }
 
 func (i Interpreter) visitThisExpr(expr *ThisExpr) any {
@@ -258,36 +568,77 @@ func (i Interpreter) visitThisExpr(expr *ThisExpr) any {
 }
 
 func (i Interpreter) visitAssignExpr(expr *AssignExpr) any {
-	value := i.evaluate(expr.value)
-	distance, ok := i.locals[expr]
-	if ok {
-		i.state.assignAt(distance, expr.name.Lexeme, value)
+	var previous any
+	value := i.assignedValue(expr.operator, func() any {
+		previous = i.lookUpVariable(expr.name, expr)
+		return previous
+	}, i.evaluate(expr.value))
+	i.assignVariable(expr.name, expr, value)
+	if expr.postfix {
+		return previous
+	}
+	return value
+}
+
+// assignVariable assigns to the variable resolved for expr,
+// constants are rejected by the resolver where possible and here otherwise.
+func (i Interpreter) assignVariable(name Token, expr Expr, value any) {
+	var assigned bool
+	if distance, ok := i.locals[expr]; ok {
+		assigned = i.state.assignAt(distance, name.Lexeme, value)
 	} else {
-		i.globals.assign(expr.name.Lexeme, value)
+		assigned = i.globals.assign(name.Lexeme, value)
+	}
+	if !assigned {
+		i.error(name, "Can't assign to constant")
+	}
+}
+
+func (i Interpreter) visitDestructureAssignExpr(expr *DestructureAssignExpr) any {
+	value := i.evaluate(expr.value)
+	array, ok := value.(*LoxArray)
+	if !ok {
+		i.error(expr.bracket, "Only arrays can be destructured")
+	}
+	// the value is fully evaluated before assigning, so `[a, b] = [b, a]` swaps
+	values := array.snapshot()
+	if len(values) != len(expr.targets) {
+		i.error(expr.bracket, fmt.Sprintf("Expect %v elements to destructure but got %v", len(expr.targets), len(values)))
+	}
+	for idx, target := range expr.targets {
+		i.assignTo(target, values[idx])
 	}
 	return value
 }
 
+func (i Interpreter) assignTo(target Expr, value any) {
+	switch target := target.(type) {
+	case *VarExpr:
+		i.assignVariable(target.name, target, value)
+	case *GetExpr:
+		object, ok := i.evaluate(target.object).(*LoxInstance)
+		if !ok {
+			i.error(target.name, "Only instance have fields")
+		}
+		object.Set(target.name, value)
+	case *SubscriptExpr:
+		array, ok := i.evaluate(target.object).(*LoxArray)
+		if !ok {
+			i.error(target.objectToken, "Only arrays can be subscripted")
+		}
+		index := i.evaluate(target.index)
+		array.update(func() {
+			array.elements[i.arrayIndex(array, index, target.indexToken)] = value
+		})
+	}
+}
+
 func (i Interpreter) visitExpressionStmt(stmt *Expression) {
 	i.evaluate(stmt.expr)
 }
 
 func (i Interpreter) visitPrintStmt(stmt *Print) {
-	value := i.evaluate(stmt.expr)
-	if value != nil {
-		switch v := value.(type) {
-		case float64:
-			if v == float64(int64(v)) {
-				fmt.Println(int64(v))
-			} else {
-				fmt.Println(v)
-			}
-		default:
-			fmt.Println(value)
-		}
-	} else {
-		fmt.Println("nil")
-	}
+	fmt.Println(i.stringify(i.evaluate(stmt.expr)))
 }
 
 func (i Interpreter) visitVarStmt(stmt *Var) {
@@ -295,9 +646,50 @@ func (i Interpreter) visitVarStmt(stmt *Var) {
 	if stmt.varValue != nil {
 		value = i.evaluate(stmt.varValue)
 	}
+	if stmt.isConst {
+		i.state.defineConst(stmt.varName.Lexeme, value)
+		return
+	}
 	i.state.define(stmt.varName.Lexeme, value)
 }
 
+func (i Interpreter) visitDestructureStmt(stmt *Destructure) {
+	value := i.evaluate(stmt.value)
+	define := i.state.define
+	if stmt.isConst {
+		define = i.state.defineConst
+	}
+
+	if stmt.isObject {
+		instance, ok := value.(*LoxInstance)
+		if !ok {
+			i.error(stmt.keyword, "Only instances can be destructured with '{'")
+		}
+		for _, name := range stmt.names {
+			define(name.Lexeme, instance.Get(i, name))
+		}
+		return
+	}
+
+	loxArray, ok := value.(*LoxArray)
+	if !ok {
+		i.error(stmt.keyword, "Only arrays can be destructured with '['")
+	}
+	array := loxArray.snapshot()
+	if stmt.rest == nil && len(array) != len(stmt.names) {
+		i.error(stmt.keyword, fmt.Sprintf("Expect %v elements to destructure but got %v", len(stmt.names), len(array)))
+	}
+	if stmt.rest != nil && len(array) < len(stmt.names) {
+		i.error(stmt.keyword, fmt.Sprintf("Expect at least %v elements to destructure but got %v", len(stmt.names), len(array)))
+	}
+	for idx, name := range stmt.names {
+		define(name.Lexeme, array[idx])
+	}
+	if stmt.rest != nil {
+		define(stmt.rest.Lexeme, NewLoxArray(append([]any{}, array[len(stmt.names):]...)))
+	}
+}
+
 func (i Interpreter) visitBlockStmt(stmt *Block) {
 	i.executeBlock(stmt, NewState(i.state))
 }
@@ -316,6 +708,19 @@ func (i Interpreter) visitWhileStmt(stmt *While) {
 	}
 }
 
+// decorate evaluates decorators from top to bottom and applies them
+// from bottom to top, so `@a @b fun f() {}` defines f as a(b(f)).
+func (i Interpreter) decorate(name Token, decorators []Expr, value any) any {
+	evaluated := make([]any, len(decorators))
+	for idx, decorator := range decorators {
+		evaluated[idx] = i.evaluate(decorator)
+	}
+	for idx := len(evaluated) - 1; idx >= 0; idx-- {
+		value = i.callValue(name, evaluated[idx], []any{value})
+	}
+	return value
+}
+
 func (i Interpreter) visitClassStmt(stmt *Class) {
 	var superclass *LoxClass = nil
 	if stmt.superclass != nil {
@@ -335,19 +740,32 @@ func (i Interpreter) visitClassStmt(stmt *Class) {
 	methods := make(map[string]*LoxFunction, 0)
 	for _, method := range stmt.methods {
 		function := NewLoxFunction(method, i.state, method.name.Lexeme == "init")
+		function.isMethod = true
 		methods[method.name.Lexeme] = function
 	}
 	cls := NewLoxClass(stmt.name.Lexeme, superclass, methods)
+	// method decorators run once, when the class is defined
+	for _, method := range stmt.methods {
+		if len(method.decorators) > 0 {
+			function := methods[method.name.Lexeme]
+			function.decorated = i.decorate(method.name, method.decorators, function)
+		}
+	}
 	if superclass != nil {
 		i.state = i.state.enclosing
 	}
-	i.state.assign(stmt.name.Lexeme, cls)
+	i.state.assign(stmt.name.Lexeme, i.decorate(stmt.name, stmt.decorators, cls))
 }
 
 func (i Interpreter) visitFunctionStmt(stmt *Function) {
 	closure := i.state
 	fn := NewLoxFunction(stmt, closure, false)
-	i.state.define(stmt.name.Lexeme, fn)
+	i.state.define(stmt.name.Lexeme, i.decorate(stmt.name, stmt.decorators, fn))
+}
+
+// returnSignal unwinds the body of a function from a return statement.
+type returnSignal struct {
+	value any
 }
 
 func (i Interpreter) visitReturnStmt(stmt *Return) {
@@ -355,10 +773,7 @@ func (i Interpreter) visitReturnStmt(stmt *Return) {
 	if stmt.value != nil {
 		result = i.evaluate(stmt.value)
 	}
-	if result == nil {
-		result = "nil"
-	}
-	panic(result)
+	panic(returnSignal{result})
 }
 
 func (i Interpreter) executeBlock(block *Block, state *State) {
@@ -403,12 +818,16 @@ func booleanCast(expr any) bool {
 	}
 }
 
+// runtimeError unwinds the task that failed. main.go prints it and
+// exits with 70, a spawned task keeps it for join instead.
+type runtimeError struct {
+	message string
+}
+
 func (i Interpreter) loxRuntimePanicBinNumeric() {
-	fmt.Fprintln(os.Stderr, "Operands must be a numbers")
-	os.Exit(70)
+	panic(runtimeError{"Operands must be a numbers"})
 }
 
 func (i Interpreter) error(token Token, msg string) {
-	fmt.Fprintf(os.Stderr, "[line %v] at '%v' %v.\n", token.Line, token.Lexeme, msg)
-	os.Exit(70)
+	panic(runtimeError{fmt.Sprintf("[line %v] at '%v' %v.", token.Line, token.Lexeme, msg)})
 }
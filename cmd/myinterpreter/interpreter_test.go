@@ -0,0 +1,49 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// run executes a lox program the way the run command does.
+func run(t *testing.T, source string) *Interpreter {
+	t.Helper()
+	scanner := NewScanner([]rune(source))
+	var tokens []Token
+	token, err := scanner.NextToken()
+	for token == nil || token.Token != EOF {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != nil {
+			tokens = append(tokens, *token)
+		}
+		token, err = scanner.NextToken()
+	}
+	tokens = append(tokens, *token)
+	parser := NewParser(tokens)
+	interp := NewInterpreter(parser)
+	resolver := NewResolver(interp)
+	stmts := parser.parseStmts()
+	resolver.resolveStmts(stmts)
+	interp.loop.virtual = true
+	for _, stmt := range stmts {
+		interp.execute(stmt)
+	}
+	interp.runLoop()
+	return interp
+}
+
+// waitGoroutines collects garbage until at most limit goroutines are left.
+func waitGoroutines(t *testing.T, limit int) {
+	t.Helper()
+	for attempt := 0; attempt < 100; attempt++ {
+		runtime.GC()
+		if runtime.NumGoroutine() <= limit {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%v goroutines are still running, expected at most %v", runtime.NumGoroutine(), limit)
+}
@@ -0,0 +1,54 @@
+package main
+
+// Iteration protocol used by spread:
+// arrays, strings (by code points) and generators are iterable, an instance is
+// iterable when it has an iterator() method returning an iterator,
+// or when it is an iterator itself. Iterators have hasNext() and next() methods.
+
+// iteratorMethod returns the bound method of an instance or nil.
+func (i Interpreter) iteratorMethod(value any, name string) any {
+	instance, ok := value.(*LoxInstance)
+	if !ok {
+		return nil
+	}
+	if method := instance.cls.findMethod(name); method != nil {
+		return i.bindMethod(instance, method)
+	}
+	return nil
+}
+
+// iterate collects all elements of an iterable value.
+func (i Interpreter) iterate(token Token, value any) []any {
+	switch v := value.(type) {
+	case *LoxArray:
+		return v.snapshot()
+	case string:
+		elements := make([]any, 0, len(v))
+		for _, char := range v {
+			elements = append(elements, string(char))
+		}
+		return elements
+	case *LoxGenerator:
+		elements := make([]any, 0)
+		for value, ok := v.state.advance(); ok; value, ok = v.state.advance() {
+			elements = append(elements, value)
+		}
+		return elements
+	}
+	if iterator := i.iteratorMethod(value, "iterator"); iterator != nil {
+		value = i.callValue(token, iterator, []any{})
+		if _, ok := value.(*LoxInstance); !ok {
+			// iterator() can return a generator or an array
+			return i.iterate(token, value)
+		}
+	}
+	hasNext, next := i.iteratorMethod(value, "hasNext"), i.iteratorMethod(value, "next")
+	if hasNext == nil || next == nil {
+		i.error(token, "Can only spread arrays, strings and iterable objects")
+	}
+	elements := make([]any, 0)
+	for booleanCast(i.callValue(token, hasNext, []any{})) {
+		elements = append(elements, i.callValue(token, next, []any{}))
+	}
+	return elements
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON objects are decoded into instances of this class,
+// their keys become fields. Keys that aren't identifiers are
+// reached with getField and setField, keys lists them.
+var jsonObjectClass = NewLoxClass("Object", nil, make(map[string]*LoxFunction))
+
+// jsonPosition converts byte offset into line and column, both starting at 1.
+func jsonPosition(text string, offset int64) (int, int) {
+	offset = min(offset, int64(len(text)))
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+func jsonParseError(text string, err error) *LoxError {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset points right after the offending character
+		line, column := jsonPosition(text, syntaxErr.Offset-1)
+		return NewLoxError(fmt.Sprintf("Invalid JSON at line %v, column %v: %v", line, column, syntaxErr.Error()))
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		line, column := jsonPosition(text, int64(len(text)))
+		return NewLoxError(fmt.Sprintf("Invalid JSON at line %v, column %v: unexpected end of input", line, column))
+	}
+	return NewLoxError(fmt.Sprintf("Invalid JSON: %v", err.Error()))
+}
+
+// fromJson converts a value produced by encoding/json into a lox value.
+// Integers stay integers, everything else with a point or exponent is a float.
+func fromJson(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if n, ok := new(big.Int).SetString(v.String(), 10); ok {
+				return normalizeInt(n)
+			}
+		}
+		f, _ := strconv.ParseFloat(v.String(), 64)
+		return f
+	case []any:
+		elements := make([]any, len(v))
+		for idx, element := range v {
+			elements[idx] = fromJson(element)
+		}
+		return NewLoxArray(elements)
+	case map[string]any:
+		instance := NewLoxInstance(jsonObjectClass)
+		for key, field := range v {
+			instance.fields[key] = fromJson(field)
+		}
+		return instance
+	}
+	return value
+}
+
+// jsonEncoder serializes lox values, seen holds the containers
+// that are being encoded to detect cycles.
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	indent string
+	seen   map[any]bool
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent != "" {
+		e.buf.WriteByte('\n')
+		e.buf.WriteString(strings.Repeat(e.indent, depth))
+	}
+}
+
+func (e *jsonEncoder) writeString(s string) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	e.buf.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func (e *jsonEncoder) enter(container any) error {
+	if e.seen[container] {
+		return errors.New("Can't convert cyclic structure to JSON")
+	}
+	e.seen[container] = true
+	return nil
+}
+
+func (e *jsonEncoder) encode(value any, depth int) error {
+	switch v := value.(type) {
+	case nil:
+		e.buf.WriteString("null")
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(v))
+	case string:
+		e.writeString(v)
+	case int64, *big.Int, *LoxDecimal, float64:
+		if f, ok := v.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			return errors.New(fmt.Sprintf("Can't convert %v to JSON", formatNumber(f)))
+		}
+		e.buf.WriteString(formatNumber(v))
+	case *LoxArray:
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		defer delete(e.seen, v)
+		elements := v.snapshot()
+		e.buf.WriteByte('[')
+		for idx, element := range elements {
+			if idx > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			if err := e.encode(element, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(elements) > 0 {
+			e.newline(depth)
+		}
+		e.buf.WriteByte(']')
+	case *LoxInstance:
+		if err := e.enter(v); err != nil {
+			return err
+		}
+		defer delete(e.seen, v)
+		// fields are unordered, sort them to get stable output
+		fields := v.snapshot()
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		e.buf.WriteByte('{')
+		for idx, key := range keys {
+			if idx > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.newline(depth + 1)
+			e.writeString(key)
+			e.buf.WriteByte(':')
+			if e.indent != "" {
+				e.buf.WriteByte(' ')
+			}
+			if err := e.encode(fields[key], depth+1); err != nil {
+				return err
+			}
+		}
+		if len(keys) > 0 {
+			e.newline(depth)
+		}
+		e.buf.WriteByte('}')
+	default:
+		return errors.New(fmt.Sprintf("Can't convert %v to JSON", v))
+	}
+	return nil
+}
+
+var jsonNatives = map[string]*NativeFunction{
+	// jsonParse returns an error value with the position of malformed input
+	"jsonParse": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		text := i.stringArg(args, 0)
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return jsonParseError(text, err)
+		}
+		// anything but the end of input after the value is an error,
+		// including stray closing brackets
+		offset := decoder.InputOffset()
+		if _, err := decoder.Token(); err != io.EOF {
+			offset += int64(len(text[offset:]) - len(strings.TrimLeft(text[offset:], " \t\r\n")))
+			line, column := jsonPosition(text, offset)
+			return NewLoxError(fmt.Sprintf("Invalid JSON at line %v, column %v: unexpected data after value", line, column))
+		}
+		return fromJson(value)
+	}),
+	// jsonStringify(value) or jsonStringify(value, indent),
+	// indent is the number of spaces used for each nesting level
+	"jsonStringify": NewNativeFunction(1, 2, func(i Interpreter, args []any) any {
+		encoder := &jsonEncoder{seen: make(map[any]bool)}
+		if len(args) == 2 {
+			indent, ok := args[1].(int64)
+			if !ok || indent < 0 {
+				i.error(i.callToken, "Indent should be a non negative integer")
+			}
+			encoder.indent = strings.Repeat(" ", int(indent))
+		}
+		if err := encoder.encode(args[0], 0); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return encoder.buf.String()
+	}),
+	// keys(instance) returns the field names in sorted order
+	"keys": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		fields := i.instanceArg(args, 0).snapshot()
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		res := make([]any, len(keys))
+		for idx, key := range keys {
+			res[idx] = key
+		}
+		return NewLoxArray(res)
+	}),
+	// getField(instance, name) returns nil when there is no such field
+	"getField": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		instance, name := i.instanceArg(args, 0), i.stringArg(args, 1)
+		instance.mu.RLock()
+		defer instance.mu.RUnlock()
+		return instance.fields[name]
+	}),
+	"setField": NewNativeFunction(3, 3, func(i Interpreter, args []any) any {
+		instance, name := i.instanceArg(args, 0), i.stringArg(args, 1)
+		instance.Set(*NewToken(name, IDENTIFIER, nil, i.callToken.Line), args[2])
+		return nil
+	}),
+}
+
+func (i Interpreter) instanceArg(args []any, idx int) *LoxInstance {
+	instance, ok := args[idx].(*LoxInstance)
+	if !ok {
+		i.error(i.callToken, fmt.Sprintf("Argument %v should be an instance", idx+1))
+	}
+	return instance
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// LoxArray is a reference type: every variable holding the array
+// sees mutations done through the others. Arrays can be shared between
+// tasks, so elements are only accessed with mu held.
+type LoxArray struct {
+	mu       sync.RWMutex
+	elements []any
+}
+
+func NewLoxArray(elements []any) *LoxArray {
+	return &LoxArray{elements: elements}
+}
+
+// snapshot copies the elements, the copy can be used while
+// calling back into Lox code without holding the lock.
+func (arr *LoxArray) snapshot() []any {
+	arr.mu.RLock()
+	defer arr.mu.RUnlock()
+	return append([]any{}, arr.elements...)
+}
+
+func (arr *LoxArray) length() int64 {
+	arr.mu.RLock()
+	defer arr.mu.RUnlock()
+	return int64(len(arr.elements))
+}
+
+// read and update run fn with the lock held, fn may panic with a runtime error.
+func (arr *LoxArray) read(fn func()) {
+	arr.mu.RLock()
+	defer arr.mu.RUnlock()
+	fn()
+}
+
+func (arr *LoxArray) update(fn func()) {
+	arr.mu.Lock()
+	defer arr.mu.Unlock()
+	fn()
+}
+
+func (arr *LoxArray) String() string {
+	return stringifier{seen: make(map[*LoxArray]bool)}.stringify(arr, false)
+}
+
+// slice copies the elements at the indices computed from the current length.
+func (arr *LoxArray) slice(indices func(length int64) []int64) *LoxArray {
+	var res []any
+	arr.read(func() {
+		from := indices(int64(len(arr.elements)))
+		res = make([]any, len(from))
+		for idx, at := range from {
+			res[idx] = arr.elements[at]
+		}
+	})
+	return NewLoxArray(res)
+}
+
+func (i Interpreter) arrayMethod(receiver *LoxArray, name Token) any {
+	method, exist := arrayMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on array", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+// insertIndex is like arrayIndex, but len(array) is allowed too,
+// the caller holds the lock.
+func (m *NativeMethod) insertIndex(i Interpreter, array *LoxArray, index any) int64 {
+	length := int64(len(array.elements))
+	idx := i.integralIndex(index, m.name)
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 || idx > length {
+		i.error(m.name, fmt.Sprintf("Index %v out of range for length %v", formatNumber(index), length))
+	}
+	return idx
+}
+
+// compareValues is the default ordering used by sort,
+// only numbers with numbers and strings with strings can be compared.
+func (m *NativeMethod) compareValues(i Interpreter, left, right any) int {
+	if isNumber(left) && isNumber(right) {
+		cmp, ok := compareNumbers(left, right)
+		if !ok {
+			i.error(m.name, "Can't sort NaN")
+		}
+		return cmp
+	}
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		i.error(m.name, "Only numbers or strings can be sorted without comparator")
+	}
+	if l < r {
+		return -1
+	} else if l > r {
+		return 1
+	}
+	return 0
+}
+
+var arrayMethods = map[string]nativeMethod{
+	"push": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		array.update(func() {
+			array.elements = append(array.elements, args[0])
+		})
+		return nil
+	}},
+	"pop": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		var last any
+		array.update(func() {
+			if len(array.elements) == 0 {
+				i.error(m.name, "Can't pop from empty array")
+			}
+			last = array.elements[len(array.elements)-1]
+			array.elements = array.elements[:len(array.elements)-1]
+		})
+		return last
+	}},
+	"insert": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		array.update(func() {
+			idx := m.insertIndex(i, array, args[0])
+			array.elements = append(array.elements, nil)
+			copy(array.elements[idx+1:], array.elements[idx:])
+			array.elements[idx] = args[1]
+		})
+		return nil
+	}},
+	"removeAt": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		var removed any
+		array.update(func() {
+			idx := i.arrayIndex(array, args[0], m.name)
+			removed = array.elements[idx]
+			array.elements = append(array.elements[:idx], array.elements[idx+1:]...)
+		})
+		return removed
+	}},
+	"clear": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		array.update(func() {
+			array.elements = make([]any, 0)
+		})
+		return nil
+	}},
+	"indexOf": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		for idx, element := range m.receiver.(*LoxArray).snapshot() {
+			if isEqual(element, args[0]) {
+				return int64(idx)
+			}
+		}
+		return int64(-1)
+	}},
+	"contains": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		for _, element := range m.receiver.(*LoxArray).snapshot() {
+			if isEqual(element, args[0]) {
+				return true
+			}
+		}
+		return false
+	}},
+	// slice(start) or slice(start, end), same as arr[start:end]
+	"slice": {1, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		var end any = nil
+		if len(args) == 2 {
+			end = args[1]
+		}
+		return array.slice(func(length int64) []int64 {
+			return i.sliceIndices(length, args[0], end, nil, m.name)
+		})
+	}},
+	"map": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		elements := m.receiver.(*LoxArray).snapshot()
+		res := make([]any, len(elements))
+		for idx, element := range elements {
+			res[idx] = i.callValue(m.name, args[0], []any{element})
+		}
+		return NewLoxArray(res)
+	}},
+	"filter": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		elements := m.receiver.(*LoxArray).snapshot()
+		res := make([]any, 0)
+		for _, element := range elements {
+			if booleanCast(i.callValue(m.name, args[0], []any{element})) {
+				res = append(res, element)
+			}
+		}
+		return NewLoxArray(res)
+	}},
+	"reduce": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		elements := m.receiver.(*LoxArray).snapshot()
+		accumulator := args[1]
+		for _, element := range elements {
+			accumulator = i.callValue(m.name, args[0], []any{accumulator, element})
+		}
+		return accumulator
+	}},
+	"forEach": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		elements := m.receiver.(*LoxArray).snapshot()
+		for _, element := range elements {
+			i.callValue(m.name, args[0], []any{element})
+		}
+		return nil
+	}},
+	// sort() or sort(comparator), comparator(a, b) returns a negative number
+	// when a goes before b. Sorting is stable and done in place, a copy
+	// is sorted and written back so the comparator can't disturb the sort.
+	"sort": {0, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		array := m.receiver.(*LoxArray)
+		elements := array.snapshot()
+		sort.SliceStable(elements, func(a, b int) bool {
+			left, right := elements[a], elements[b]
+			if len(args) == 0 {
+				return m.compareValues(i, left, right) < 0
+			}
+			res := i.callValue(m.name, args[0], []any{left, right})
+			if !isNumber(res) {
+				i.error(m.name, "Comparator should return a number")
+			}
+			cmp, _ := compareNumbers(res, int64(0))
+			return cmp < 0
+		})
+		array.update(func() {
+			array.elements = elements
+		})
+		return array
+	}},
+}
@@ -4,3 +4,15 @@ type LoxCallable interface {
 	arity() int
 	call(i Interpreter, args []any) any
 }
+
+// Callables accepting a varying number of arguments also implement
+// arityRange, max is -1 when there is no upper limit.
+type variadicCallable interface {
+	arityRange() (min int, max int)
+}
+
+// Lox functions and classes accept named arguments,
+// parameters returns the declaration they are matched against.
+type parametrized interface {
+	parameters() *Function
+}
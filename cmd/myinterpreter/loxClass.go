@@ -21,6 +21,20 @@ func (cls *LoxClass) arity() int {
 	return 0
 }
 
+func (cls *LoxClass) arityRange() (int, int) {
+	if initializer := cls.findMethod("init"); initializer != nil {
+		return initializer.arityRange()
+	}
+	return 0, 0
+}
+
+func (cls *LoxClass) parameters() *Function {
+	if initializer := cls.findMethod("init"); initializer != nil {
+		return initializer.parameters()
+	}
+	return nil
+}
+
 func (cls *LoxClass) call(i Interpreter, args []any) any {
 	instance := NewLoxInstance(cls)
 	initializer := cls.findMethod("init")
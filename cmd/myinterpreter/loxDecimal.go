@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	ROUND_HALF_EVEN RoundingMode = iota
+	ROUND_HALF_UP
+	ROUND_HALF_DOWN
+	ROUND_UP
+	ROUND_DOWN
+	ROUND_CEILING
+	ROUND_FLOOR
+)
+
+var roundingModes = map[string]RoundingMode{
+	"half_even": ROUND_HALF_EVEN,
+	"half_up":   ROUND_HALF_UP,
+	"half_down": ROUND_HALF_DOWN,
+	"up":        ROUND_UP,
+	"down":      ROUND_DOWN,
+	"ceiling":   ROUND_CEILING,
+	"floor":     ROUND_FLOOR,
+}
+
+// DecimalContext controls results that can't be represented exactly,
+// i.e. division. precision is the number of digits after the decimal point.
+// It is never modified, decimalContext() replaces it.
+type DecimalContext struct {
+	precision int
+	rounding  RoundingMode
+}
+
+func NewDecimalContext() *DecimalContext {
+	return &DecimalContext{
+		precision: 28,
+		rounding:  ROUND_HALF_EVEN,
+	}
+}
+
+// LoxDecimal is an exact base 10 number: unscaled * 10^-scale.
+type LoxDecimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+func NewLoxDecimal(unscaled *big.Int, scale int) *LoxDecimal {
+	return &LoxDecimal{
+		unscaled: unscaled,
+		scale:    scale,
+	}
+}
+
+func parseDecimal(s string) (*LoxDecimal, error) {
+	s = strings.TrimSpace(s)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	unscaled, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok || intPart == "" || intPart == "-" || intPart == "+" || strings.ContainsAny(fracPart, "+-") {
+		return nil, errors.New(fmt.Sprintf("Can't convert '%v' to decimal", s))
+	}
+	return NewLoxDecimal(unscaled, len(fracPart)), nil
+}
+
+// toDecimal converts any lox number (or numeric string) to a decimal.
+// Floats are converted using their shortest decimal representation.
+func toDecimal(value any) (*LoxDecimal, error) {
+	switch v := value.(type) {
+	case int64:
+		return NewLoxDecimal(big.NewInt(v), 0), nil
+	case *big.Int:
+		return NewLoxDecimal(new(big.Int).Set(v), 0), nil
+	case *LoxDecimal:
+		return v, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, errors.New("Can't convert inf or nan to decimal")
+		}
+		return parseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return parseDecimal(v)
+	}
+	return nil, errors.New("Expect number or string")
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// rescale returns unscaled value of d with the given scale (scale >= d.scale).
+func (d *LoxDecimal) rescale(scale int) *big.Int {
+	return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+}
+
+func alignDecimals(a, b *LoxDecimal) (*big.Int, *big.Int, int) {
+	scale := max(a.scale, b.scale)
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+func (d *LoxDecimal) Add(other *LoxDecimal) *LoxDecimal {
+	a, b, scale := alignDecimals(d, other)
+	return NewLoxDecimal(a.Add(a, b), scale)
+}
+
+func (d *LoxDecimal) Sub(other *LoxDecimal) *LoxDecimal {
+	a, b, scale := alignDecimals(d, other)
+	return NewLoxDecimal(a.Sub(a, b), scale)
+}
+
+func (d *LoxDecimal) Mul(other *LoxDecimal) *LoxDecimal {
+	return NewLoxDecimal(new(big.Int).Mul(d.unscaled, other.unscaled), d.scale+other.scale)
+}
+
+// Quo divides with ctx.precision digits after the point.
+// Exact quotients that need fewer digits are not padded.
+func (d *LoxDecimal) Quo(other *LoxDecimal, ctx *DecimalContext) *LoxDecimal {
+	// d/other = (d.unscaled * 10^(precision + other.scale - d.scale)) / other.unscaled * 10^-precision
+	num := new(big.Int).Set(d.unscaled)
+	den := new(big.Int).Set(other.unscaled)
+	shift := ctx.precision + other.scale - d.scale
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return NewLoxDecimal(divRound(num, den, ctx.rounding), ctx.precision).normalize()
+}
+
+// Rem is the remainder of truncated division, it has the sign of d.
+func (d *LoxDecimal) Rem(other *LoxDecimal) *LoxDecimal {
+	a, b, scale := alignDecimals(d, other)
+	return NewLoxDecimal(a.Rem(a, b), scale)
+}
+
+func (d *LoxDecimal) Pow(exponent int64) *LoxDecimal {
+	unscaled := new(big.Int).Exp(d.unscaled, big.NewInt(exponent), nil)
+	return NewLoxDecimal(unscaled, d.scale*int(exponent))
+}
+
+func (d *LoxDecimal) Neg() *LoxDecimal {
+	return NewLoxDecimal(new(big.Int).Neg(d.unscaled), d.scale)
+}
+
+func (d *LoxDecimal) Cmp(other *LoxDecimal) int {
+	a, b, _ := alignDecimals(d, other)
+	return a.Cmp(b)
+}
+
+func (d *LoxDecimal) IsZero() bool {
+	return d.unscaled.Sign() == 0
+}
+
+// Round returns d with at most places digits after the point.
+func (d *LoxDecimal) Round(places int, mode RoundingMode) *LoxDecimal {
+	if places >= d.scale {
+		return d
+	}
+	return NewLoxDecimal(divRound(d.unscaled, pow10(d.scale-places), mode), places)
+}
+
+// normalize drops trailing zeros after the decimal point.
+func (d *LoxDecimal) normalize() *LoxDecimal {
+	unscaled := new(big.Int).Set(d.unscaled)
+	scale := d.scale
+	ten := big.NewInt(10)
+	rem := new(big.Int)
+	for scale > 0 {
+		quo, r := new(big.Int).QuoRem(unscaled, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		unscaled = quo
+		scale--
+	}
+	return NewLoxDecimal(unscaled, scale)
+}
+
+func (d *LoxDecimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *LoxDecimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale <= 0 {
+		return sign + digits + strings.Repeat("0", -d.scale)
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// divRound divides num by den and rounds the result according to mode.
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+	sign := int64(num.Sign() * den.Sign())
+	// compare the remainder with half of the divisor
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.Cmp(new(big.Int).Abs(den))
+
+	awayFromZero := false
+	switch mode {
+	case ROUND_UP:
+		awayFromZero = true
+	case ROUND_DOWN:
+		awayFromZero = false
+	case ROUND_CEILING:
+		awayFromZero = sign > 0
+	case ROUND_FLOOR:
+		awayFromZero = sign < 0
+	case ROUND_HALF_UP:
+		awayFromZero = half >= 0
+	case ROUND_HALF_DOWN:
+		awayFromZero = half > 0
+	case ROUND_HALF_EVEN:
+		awayFromZero = half > 0 || (half == 0 && quo.Bit(0) == 1)
+	}
+	if awayFromZero {
+		quo.Add(quo, big.NewInt(sign))
+	}
+	return quo
+}
@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+// LoxError is returned by natives that can fail for reasons outside
+// of the script (missing files, malformed input), so scripts can
+// check the result with isError() instead of being terminated.
+type LoxError struct {
+	message string
+}
+
+func NewLoxError(message string) *LoxError {
+	return &LoxError{message: message}
+}
+
+func (e *LoxError) Get(i Interpreter, name Token) any {
+	if name.Lexeme == "message" {
+		return e.message
+	}
+	i.error(name, fmt.Sprintf("Undefined property '%v' of error", name.Lexeme))
+	return nil
+}
+
+func (e *LoxError) String() string {
+	return fmt.Sprintf("Error: %v", e.message)
+}
@@ -4,10 +4,19 @@ import (
 	"fmt"
 )
 
+// missingArgument takes the place of parameters skipped
+// by named arguments, the default value is used instead.
+type missingArgument struct{}
+
 type LoxFunction struct {
 	declaration   *Function
 	closure       *State
 	isInitialiser bool
+	// method of a class not bound to an instance yet
+	isMethod bool
+	// what the decorators of a method returned when the class was defined,
+	// it is bound on access instead of the method, see Interpreter.bindMethod
+	decorated any
 }
 
 func NewLoxFunction(declaration *Function, closure *State, isInitialiser bool) *LoxFunction {
@@ -22,29 +31,106 @@ func (lf *LoxFunction) arity() int {
 	return len(lf.declaration.arguments)
 }
 
-func (lf *LoxFunction) call(i Interpreter, args []any) (retVal any) {
+// arityRange counts parameters with default values as optional,
+// rest parameter removes the upper limit.
+func (lf *LoxFunction) arityRange() (int, int) {
+	required := 0
+	for _, value := range lf.declaration.defaults {
+		if value == nil {
+			required++
+		}
+	}
+	if lf.declaration.rest != nil {
+		return required, -1
+	}
+	return required, len(lf.declaration.arguments)
+}
+
+func (lf *LoxFunction) parameters() *Function {
+	return lf.declaration
+}
+
+func (lf *LoxFunction) call(i Interpreter, args []any) any {
+	if lf.isMethod {
+		// only decorators get hold of unbound methods
+		return lf.bind(lf.receiver(i)).call(i, args)
+	}
+	funState := lf.bindArguments(i, args)
+	if lf.declaration.isGenerator {
+		return NewLoxGenerator(lf, i, funState)
+	} else if lf.declaration.isAsync {
+		return i.startAsync(lf, funState)
+	}
+	retVal := lf.runBody(i, funState)
+	if lf.isInitialiser {
+		return lf.closure.accessAt(0, "this")
+	}
+	return retVal
+}
+
+// bindArguments creates the function scope with parameters defined.
+func (lf *LoxFunction) bindArguments(i Interpreter, args []any) *State {
+	funState := NewState(lf.closure)
+	// default values are evaluated in the function scope,
+	// so they can refer to the previous parameters
+	i.state = funState
+	for idx, param := range lf.declaration.arguments {
+		var value any = nil
+		missing := idx >= len(args)
+		if !missing {
+			_, missing = args[idx].(missingArgument)
+		}
+		if !missing {
+			value = args[idx]
+		} else if lf.declaration.defaults[idx] != nil {
+			value = i.evaluate(lf.declaration.defaults[idx])
+		} else {
+			i.error(i.callToken, fmt.Sprintf("Missing argument '%v'", param.Lexeme))
+		}
+		funState.define(param.Lexeme, value)
+	}
+	if lf.declaration.rest != nil {
+		rest := make([]any, 0)
+		if len(args) > len(lf.declaration.arguments) {
+			rest = append(rest, args[len(lf.declaration.arguments):]...)
+		}
+		funState.define(lf.declaration.rest.Lexeme, NewLoxArray(rest))
+	}
+	return funState
+}
+
+// runBody executes the body in the prepared scope and returns the returned value.
+func (lf *LoxFunction) runBody(i Interpreter, funState *State) (retVal any) {
 	defer func() {
 		if err := recover(); err != nil {
-			if lf.isInitialiser {
-				retVal = lf.closure.accessAt(0, "this")
-			} else if retVal != "nil" {
-				retVal = err
-			} else {
-				retVal = nil
+			signal, ok := err.(returnSignal)
+			if !ok {
+				panic(err)
 			}
-			return
+			retVal = signal.value
 		}
 	}()
-	funState := NewState(lf.closure)
-	for i, arg := range args {
-		funState.define(lf.declaration.arguments[i].Lexeme, arg)
-	}
 	i.executeBlock(lf.declaration.body, funState)
+	return nil
+}
 
-	if lf.isInitialiser {
-		return lf.closure.accessAt(0, "this")
+// receiver finds the instance an unbound method is called on: the nearest
+// `this` of the calling scopes whose class has the method. A decorated
+// method accessed on an instance binds the decorator result to the
+// instance, so the wrapper calling the method provides its `this`.
+func (lf *LoxFunction) receiver(i Interpreter) *LoxInstance {
+	for scope := i.state; scope != nil; scope = scope.enclosing {
+		instance, ok := scope.local("this").(*LoxInstance)
+		if !ok {
+			continue
+		}
+		for cls := instance.cls; cls != nil; cls = cls.superclass {
+			if cls.methods[lf.declaration.name.Lexeme] == lf {
+				return instance
+			}
+		}
 	}
-
+	i.error(i.callToken, fmt.Sprintf("Method '%v' is called without an instance", lf.declaration.name.Lexeme))
 	return nil
 }
 
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// generatorState is shared between a generator and the goroutine
+// running its body. The two never run at the same time: the body runs
+// only between a request for the next value and the following yield.
+// Tasks sharing a generator take turns, mu is held while advancing.
+type generatorState struct {
+	mu     sync.Mutex
+	values chan any
+	resume chan struct{}
+	// stop is closed when the generator is closed or garbage collected,
+	// a body waiting in yield unwinds and the goroutine exits
+	stop     chan struct{}
+	stopOnce sync.Once
+	started  bool
+	done     bool
+	// value fetched by hasNext and not returned by next yet
+	peeked    bool
+	peekValue any
+	startBody func()
+	// panic that ended the body, advance raises it in the consumer
+	failure any
+}
+
+// generatorStopped unwinds the body of a stopped generator.
+type generatorStopped struct{}
+
+// Bodies of abandoned generators wait in yield until the finalizer
+// stops them. To not depend on the heap growing enough for a collection,
+// starting a body forces one whenever the number of running bodies
+// doubles past runningGeneratorsLimit.
+const runningGeneratorsLimit = 1024
+
+var (
+	runningGenerators atomic.Int64
+	collectGenerators atomic.Int64
+)
+
+// LoxGenerator is returned by calling a function containing yield.
+// The body is not started until the first value is requested.
+// The goroutine doesn't reference LoxGenerator itself, so an abandoned
+// generator gets garbage collected and its finalizer stops the goroutine.
+type LoxGenerator struct {
+	function *LoxFunction
+	state    *generatorState
+}
+
+func NewLoxGenerator(function *LoxFunction, i Interpreter, funState *State) *LoxGenerator {
+	state := &generatorState{
+		values: make(chan any),
+		resume: make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+	generator := &LoxGenerator{function: function, state: state}
+	i.generator = state
+	// the caller's scope may hold the generator, it must not be kept alive by the body
+	i.state, i.coroutine = funState, nil
+	start := func() {
+		runningGenerators.Add(1)
+		defer runningGenerators.Add(-1)
+		defer close(state.values)
+		defer func() {
+			// return statements and stopping both end the generator,
+			// runtime errors are raised by advance in the consumer,
+			// anything else is a bug that must not be swallowed
+			if err := recover(); err != nil {
+				switch err.(type) {
+				case returnSignal, generatorStopped:
+				case runtimeError:
+					state.failure = err
+				default:
+					panic(err)
+				}
+			}
+		}()
+		i.executeBlock(function.declaration.body, funState)
+	}
+	state.startBody = start
+	runtime.SetFinalizer(generator, func(g *LoxGenerator) { g.state.close() })
+	return generator
+}
+
+func (g *LoxGenerator) String() string {
+	return fmt.Sprintf("<generator %v>", g.function.declaration.name.Lexeme)
+}
+
+// advance runs the body until the next yield, ok is false when it has finished.
+func (s *generatorState) advance() (value any, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.advanceLocked()
+}
+
+func (s *generatorState) advanceLocked() (value any, ok bool) {
+	if s.stopped() {
+		s.done, s.peeked = true, false
+	}
+	if s.peeked {
+		s.peeked = false
+		return s.peekValue, true
+	}
+	if s.done {
+		return nil, false
+	}
+	if !s.started {
+		s.started = true
+		if running := runningGenerators.Load(); running >= max(collectGenerators.Load(), runningGeneratorsLimit) {
+			collectGenerators.Store(2 * running)
+			runtime.GC()
+		}
+		go s.startBody()
+	} else {
+		select {
+		case s.resume <- struct{}{}:
+		case <-s.stop:
+			s.done = true
+			return nil, false
+		}
+	}
+	value, ok = <-s.values
+	if !ok {
+		s.done = true
+		if s.failure != nil {
+			failure := s.failure
+			s.failure = nil
+			panic(failure)
+		}
+	}
+	return value, ok
+}
+
+func (s *generatorState) hasNext() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.peeked {
+		s.peekValue, s.peeked = s.advanceLocked()
+	}
+	return s.peeked
+}
+
+// yield is called from the goroutine running the body.
+func (s *generatorState) yield(value any) {
+	s.values <- value
+	select {
+	case <-s.resume:
+	case <-s.stop:
+		panic(generatorStopped{})
+	}
+}
+
+func (s *generatorState) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// close doesn't take mu, the body itself or the finalizer may call it
+// while a consumer waits in advance.
+func (s *generatorState) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (i Interpreter) visitYieldExpr(expr *YieldExpr) any {
+	if i.generator == nil {
+		i.error(expr.keyword, "Can't yield outside of a generator")
+	}
+	var value any = nil
+	if expr.value != nil {
+		value = i.evaluate(expr.value)
+	}
+	i.generator.yield(value)
+	return nil
+}
+
+func (i Interpreter) generatorMethod(receiver *LoxGenerator, name Token) any {
+	method, exist := generatorMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on generator", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+var generatorMethods = map[string]nativeMethod{
+	// next returns the next yielded value, nil after the generator has finished
+	"next": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		value, _ := m.receiver.(*LoxGenerator).state.advance()
+		return value
+	}},
+	"hasNext": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return m.receiver.(*LoxGenerator).state.hasNext()
+	}},
+	// close stops the generator, its body doesn't run anymore
+	"close": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		m.receiver.(*LoxGenerator).state.close()
+		return nil
+	}},
+}
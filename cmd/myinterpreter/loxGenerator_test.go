@@ -0,0 +1,44 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+const naturals = `
+fun naturals() {
+    var n = 0;
+    while (true) {
+        yield n;
+        n = n + 1;
+    }
+}
+`
+
+func TestAbandonedGeneratorsDoNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	run(t, naturals+`
+for (var i = 0; i < 5000; i = i + 1) {
+    var g = naturals();
+    g.next();
+    g.next();
+}
+`)
+	waitGoroutines(t, before)
+}
+
+func TestClosedGeneratorsStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	interp := run(t, naturals+`
+var generators = [];
+for (var i = 0; i < 100; i = i + 1) {
+    var g = naturals();
+    g.next();
+    generators.push(g);
+}
+for (var i = 0; i < 100; i = i + 1) generators[i].close();
+`)
+	// the generators are still referenced, only close can stop them
+	waitGoroutines(t, before)
+	runtime.KeepAlive(interp)
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"sync"
 )
 
 type LoxInstance struct {
 	cls    *LoxClass
 	fields map[string]any
+	// fields can be set from several tasks at once
+	mu sync.RWMutex
 }
 
 func NewLoxInstance(cls *LoxClass) *LoxInstance {
@@ -21,20 +23,45 @@ func (instance *LoxInstance) String() string {
 	return fmt.Sprintf("%v instance", instance.cls.String())
 }
 
-func (instance *LoxInstance) Get(name Token) any {
+func (instance *LoxInstance) Get(i Interpreter, name Token) any {
+	instance.mu.RLock()
 	value, ok := instance.fields[name.Lexeme]
+	instance.mu.RUnlock()
 	if ok {
 		return value
 	}
-	method := instance.cls.findMethod(name.Lexeme)
-	if method != nil {
-		return method.bind(instance)
+	if method := instance.cls.findMethod(name.Lexeme); method != nil {
+		return i.bindMethod(instance, method)
 	}
-	fmt.Fprintf(os.Stderr, "[line %v] Undefined property '%v'", name.Line, name.Lexeme)
-	os.Exit(70)
-	return nil
+	panic(runtimeError{fmt.Sprintf("[line %v] Undefined property '%v'", name.Line, name.Lexeme)})
 }
 
 func (instance *LoxInstance) Set(name Token, value any) {
+	instance.mu.Lock()
 	instance.fields[name.Lexeme] = value
+	instance.mu.Unlock()
+}
+
+// bindMethod binds method to instance. For a decorated method the
+// decorator result is bound instead: a function returned by the decorator
+// gets `this`, and the method it wraps finds it there when it is called.
+func (i Interpreter) bindMethod(instance *LoxInstance, method *LoxFunction) any {
+	if method.decorated == nil {
+		return method.bind(instance)
+	}
+	if function, ok := method.decorated.(*LoxFunction); ok {
+		return function.bind(instance)
+	}
+	return method.decorated
+}
+
+// snapshot returns a copy of the fields that can be read without locking.
+func (instance *LoxInstance) snapshot() map[string]any {
+	instance.mu.RLock()
+	defer instance.mu.RUnlock()
+	fields := make(map[string]any, len(instance.fields))
+	for name, value := range instance.fields {
+		fields[name] = value
+	}
+	return fields
 }
@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// LoxModule is a namespace of natives, e.g. `math.sqrt(2)`.
+// Functions are bound on access like native methods,
+// so their errors point to the line of the call.
+type LoxModule struct {
+	name      string
+	functions map[string]nativeMethod
+	constants map[string]any
+}
+
+func NewLoxModule(name string, functions map[string]nativeMethod, constants map[string]any) *LoxModule {
+	return &LoxModule{
+		name:      name,
+		functions: functions,
+		constants: constants,
+	}
+}
+
+func (module *LoxModule) Get(i Interpreter, name Token) any {
+	if value, exist := module.constants[name.Lexeme]; exist {
+		return value
+	}
+	if function, exist := module.functions[name.Lexeme]; exist {
+		return NewNativeMethod(name, module, function)
+	}
+	i.error(name, fmt.Sprintf("Undefined member '%v' of module %v", name.Lexeme, module.name))
+	return nil
+}
+
+func (module *LoxModule) String() string {
+	return fmt.Sprintf("<module %v>", module.name)
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Lox numbers form a small tower:
+// int64 for integer literals, *big.Int once an integer overflows,
+// *LoxDecimal for exact decimal arithmetic and float64 for everything
+// with a decimal point. Mixing integers with floats promotes to float,
+// mixing integers with decimals promotes to decimal.
+// Floats and decimals can't be mixed in arithmetic, that would lose exactness.
+
+type numberKind int
+
+const (
+	KIND_INT numberKind = iota
+	KIND_BIGINT
+	KIND_DECIMAL
+	KIND_FLOAT
+)
+
+// maxBigIntBits bounds results of shifts and powers,
+// a bigger integer would exhaust memory before it is useful.
+const maxBigIntBits = 1 << 24
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case int64, float64, *big.Int, *LoxDecimal:
+		return true
+	}
+	return false
+}
+
+func kindOf(value any) numberKind {
+	switch value.(type) {
+	case int64:
+		return KIND_INT
+	case *big.Int:
+		return KIND_BIGINT
+	case *LoxDecimal:
+		return KIND_DECIMAL
+	}
+	return KIND_FLOAT
+}
+
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case *LoxDecimal:
+		f, _ := v.Rat().Float64()
+		return f
+	}
+	panic("unreachable")
+}
+
+func toBigInt(value any) *big.Int {
+	switch v := value.(type) {
+	case int64:
+		return big.NewInt(v)
+	case *big.Int:
+		return v
+	}
+	panic("unreachable")
+}
+
+// toRat is exact for every finite number.
+func toRat(value any) *big.Rat {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case *LoxDecimal:
+		return v.Rat()
+	case float64:
+		return new(big.Rat).SetFloat64(v)
+	}
+	panic("unreachable")
+}
+
+// powTooLarge tells if base ** exponent would need more than maxBigIntBits.
+func powTooLarge(base *big.Int, exponent int64) bool {
+	bits := int64(base.BitLen())
+	return bits > 1 && exponent > maxBigIntBits/(bits-1)
+}
+
+// normalizeInt turns a big integer back into int64 when it fits.
+func normalizeInt(value *big.Int) any {
+	if value.IsInt64() {
+		return value.Int64()
+	}
+	return value
+}
+
+// formatNumber is the single place where numbers are turned into text
+// by print, str and the other stringifying natives.
+// Whole floats are printed without a decimal point, the same as integers,
+// so existing programs keep their output.
+func formatNumber(value any) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *big.Int:
+		return v.String()
+	case *LoxDecimal:
+		return v.String()
+	case float64:
+		if math.IsInf(v, 1) {
+			return "inf"
+		} else if math.IsInf(v, -1) {
+			return "-inf"
+		} else if math.IsNaN(v) {
+			return "nan"
+		}
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	panic("unreachable")
+}
+
+// formatLiteral is the text of number literals in token and AST output,
+// whole numbers always keep the decimal point there, whatever their type.
+func formatLiteral(value any) string {
+	switch v := value.(type) {
+	case int64, *big.Int:
+		return fmt.Sprintf("%v.0", v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatFloat(v, 'f', 1, 64)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+func addInt(a, b int64) (int64, bool) {
+	res := a + b
+	if (a > 0 && b > 0 && res < 0) || (a < 0 && b < 0 && res >= 0) {
+		return 0, false
+	}
+	return res, true
+}
+
+func subInt(a, b int64) (int64, bool) {
+	res := a - b
+	if (a >= 0 && b < 0 && res < 0) || (a < 0 && b > 0 && res >= 0) {
+		return 0, false
+	}
+	return res, true
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	res := a * b
+	if res/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return res, true
+}
+
+// compareNumbers returns -1, 0 or 1, comparing integers and decimals exactly
+// and falling back to float comparison only for infinities.
+// ok is false when the operands are unordered (NaN is involved).
+func compareNumbers(left, right any) (res int, ok bool) {
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+	if lok && rok {
+		if l < r {
+			return -1, true
+		} else if l > r {
+			return 1, true
+		}
+		return 0, true
+	}
+	lf, rf := toFloat(left), toFloat(right)
+	if math.IsNaN(lf) || math.IsNaN(rf) {
+		return 0, false
+	}
+	if math.IsInf(lf, 0) || math.IsInf(rf, 0) {
+		if lf < rf {
+			return -1, true
+		} else if lf > rf {
+			return 1, true
+		}
+		return 0, true
+	}
+	return toRat(left).Cmp(toRat(right)), true
+}
+
+// Arithmetic on two numbers.
+// Integer operands stay integers and are promoted to bigint on overflow.
+// Division of two integers stays integral only when it is exact,
+// otherwise the result is a float.
+// Integer division `~/` rounds towards negative infinity and `%` is
+// the matching remainder, it takes the sign of the divisor,
+// so `(a ~/ b) * b + a % b == a` for every kind of number.
+func (i Interpreter) arithmetic(operator Token, left, right any) any {
+	if !isNumber(left) || !isNumber(right) {
+		i.loxRuntimePanicBinNumeric()
+	}
+	leftKind, rightKind := kindOf(left), kindOf(right)
+	kind := max(leftKind, rightKind)
+	if kind == KIND_FLOAT && (leftKind == KIND_DECIMAL || rightKind == KIND_DECIMAL) {
+		i.error(operator, "Can't mix float and decimal, convert with decimal()")
+	}
+
+	switch kind {
+	case KIND_INT:
+		return i.intArithmetic(operator, left.(int64), right.(int64))
+	case KIND_BIGINT:
+		return i.bigIntArithmetic(operator, toBigInt(left), toBigInt(right))
+	case KIND_DECIMAL:
+		return i.decimalArithmetic(operator, left, right)
+	}
+
+	lf, rf := toFloat(left), toFloat(right)
+	switch operator.Token {
+	case PLUS:
+		return lf + rf
+	case MINUS:
+		return lf - rf
+	case STAR:
+		return lf * rf
+	case SLASH:
+		return lf / rf
+	case PERCENT:
+		res := math.Mod(lf, rf)
+		if res != 0 && (res < 0) != (rf < 0) {
+			res += rf
+		}
+		return res
+	case STAR_STAR:
+		return math.Pow(lf, rf)
+	case TILDE_SLASH:
+		return math.Floor(lf / rf)
+	}
+	panic("unreachable")
+}
+
+func (i Interpreter) intArithmetic(operator Token, l, r int64) any {
+	var res int64
+	var ok bool
+	switch operator.Token {
+	case PLUS:
+		res, ok = addInt(l, r)
+	case MINUS:
+		res, ok = subInt(l, r)
+	case STAR:
+		res, ok = mulInt(l, r)
+	case SLASH:
+		if r == 0 {
+			i.error(operator, "Division by zero")
+		}
+		if l%r != 0 {
+			return float64(l) / float64(r)
+		}
+		res, ok = l/r, !(l == math.MinInt64 && r == -1)
+	case PERCENT:
+		if r == 0 {
+			i.error(operator, "Division by zero")
+		}
+		if r == -1 {
+			return int64(0)
+		}
+		res, ok = l%r, true
+		if res != 0 && (res < 0) != (r < 0) {
+			res += r
+		}
+	case STAR_STAR:
+		if r < 0 {
+			return math.Pow(float64(l), float64(r))
+		}
+		if powTooLarge(big.NewInt(l), r) {
+			i.error(operator, "Exponent is too large")
+		}
+		return normalizeInt(new(big.Int).Exp(big.NewInt(l), big.NewInt(r), nil))
+	case TILDE_SLASH:
+		if r == 0 {
+			i.error(operator, "Division by zero")
+		}
+		res, ok = l/r, !(l == math.MinInt64 && r == -1)
+		if l%r != 0 && (l < 0) != (r < 0) {
+			res--
+		}
+	}
+	if !ok {
+		return i.bigIntArithmetic(operator, big.NewInt(l), big.NewInt(r))
+	}
+	return res
+}
+
+// bigIntArithmetic turns integral results that fit back into int64.
+func (i Interpreter) bigIntArithmetic(operator Token, l, r *big.Int) any {
+	res := i.bigIntResult(operator, l, r)
+	if res, ok := res.(*big.Int); ok {
+		return normalizeInt(res)
+	}
+	return res
+}
+
+func (i Interpreter) bigIntResult(operator Token, l, r *big.Int) any {
+	res := new(big.Int)
+	switch operator.Token {
+	case PLUS:
+		return res.Add(l, r)
+	case MINUS:
+		return res.Sub(l, r)
+	case STAR:
+		return res.Mul(l, r)
+	case SLASH:
+		if r.Sign() == 0 {
+			i.error(operator, "Division by zero")
+		}
+		quo, rem := res.QuoRem(l, r, new(big.Int))
+		if rem.Sign() != 0 {
+			f, _ := new(big.Rat).SetFrac(l, r).Float64()
+			return f
+		}
+		return quo
+	case PERCENT:
+		if r.Sign() == 0 {
+			i.error(operator, "Division by zero")
+		}
+		res.Rem(l, r)
+		if res.Sign() != 0 && res.Sign() != r.Sign() {
+			res.Add(res, r)
+		}
+		return res
+	case STAR_STAR:
+		if r.Sign() < 0 {
+			return math.Pow(toFloat(l), toFloat(r))
+		}
+		if l.BitLen() > 1 && (!r.IsInt64() || powTooLarge(l, r.Int64())) {
+			i.error(operator, "Exponent is too large")
+		}
+		return res.Exp(l, r, nil)
+	case TILDE_SLASH:
+		if r.Sign() == 0 {
+			i.error(operator, "Division by zero")
+		}
+		return floorDiv(l, r)
+	}
+	panic("unreachable")
+}
+
+// floorDiv rounds the quotient towards negative infinity.
+func floorDiv(l, r *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(l, r, new(big.Int))
+	if rem.Sign() != 0 && rem.Sign() != r.Sign() {
+		quo.Sub(quo, big.NewInt(1))
+	}
+	return quo
+}
+
+func (i Interpreter) decimalArithmetic(operator Token, left, right any) any {
+	l, _ := toDecimal(left)
+	r, _ := toDecimal(right)
+	switch operator.Token {
+	case PLUS:
+		return l.Add(r)
+	case MINUS:
+		return l.Sub(r)
+	case STAR:
+		return l.Mul(r)
+	case SLASH:
+		if r.IsZero() {
+			i.error(operator, "Division by zero")
+		}
+		return l.Quo(r, i.decimalContext.Load())
+	case PERCENT:
+		if r.IsZero() {
+			i.error(operator, "Division by zero")
+		}
+		rem := l.Rem(r)
+		if rem.unscaled.Sign() != 0 && rem.unscaled.Sign() != r.unscaled.Sign() {
+			rem = rem.Add(r)
+		}
+		return rem
+	case STAR_STAR:
+		exponent, ok := right.(int64)
+		if !ok {
+			i.error(operator, "Decimal can only be raised to an integer power")
+		}
+		magnitude := exponent
+		if magnitude < 0 {
+			magnitude = -magnitude
+		}
+		if magnitude < 0 || powTooLarge(l.unscaled, magnitude) || (l.scale > 0 && magnitude > maxBigIntBits/int64(l.scale)) {
+			i.error(operator, "Exponent is too large")
+		}
+		if exponent < 0 {
+			if l.IsZero() {
+				i.error(operator, "Division by zero")
+			}
+			return NewLoxDecimal(big.NewInt(1), 0).Quo(l.Pow(-exponent), i.decimalContext.Load())
+		}
+		return l.Pow(exponent)
+	case TILDE_SLASH:
+		if r.IsZero() {
+			i.error(operator, "Division by zero")
+		}
+		a, b, _ := alignDecimals(l, r)
+		return NewLoxDecimal(divRound(a, b, ROUND_FLOOR), 0)
+	}
+	panic("unreachable")
+}
+
+func isInteger(value any) bool {
+	switch value.(type) {
+	case int64, *big.Int:
+		return true
+	}
+	return false
+}
+
+// Bitwise operators work on integers only, bigint operands work
+// as if they had infinite two's complement representation.
+// Left shift of int64 is promoted to bigint when the result doesn't fit,
+// bigint results that fit again are turned back into int64.
+func (i Interpreter) bitwise(operator Token, left, right any) any {
+	if !isInteger(left) || !isInteger(right) {
+		i.error(operator, "Operands must be integers")
+	}
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+
+	switch operator.Token {
+	case LESS_LESS, GREATER_GREATER:
+		if !rok || r < 0 {
+			i.error(operator, "Shift count must be a non negative integer")
+		}
+		if operator.Token == GREATER_GREATER {
+			if lok {
+				return l >> min(r, 63)
+			}
+			return normalizeInt(new(big.Int).Rsh(toBigInt(left), uint(min(r, maxBigIntBits))))
+		}
+		if lok && l == 0 {
+			return int64(0)
+		}
+		if int64(toBigInt(left).BitLen())+r > maxBigIntBits {
+			i.error(operator, fmt.Sprintf("Shift count %v is too large", r))
+		}
+		return normalizeInt(new(big.Int).Lsh(toBigInt(left), uint(r)))
+	}
+
+	if lok && rok {
+		switch operator.Token {
+		case AMPERSAND:
+			return l & r
+		case PIPE:
+			return l | r
+		case CARET:
+			return l ^ r
+		}
+	}
+	res := new(big.Int)
+	switch operator.Token {
+	case AMPERSAND:
+		return normalizeInt(res.And(toBigInt(left), toBigInt(right)))
+	case PIPE:
+		return normalizeInt(res.Or(toBigInt(left), toBigInt(right)))
+	case CARET:
+		return normalizeInt(res.Xor(toBigInt(left), toBigInt(right)))
+	}
+	panic("unreachable")
+}
+
+// isEqual implements '==' for any pair of lox values.
+// Numbers are equal by value regardless of representation.
+func isEqual(left, right any) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if isNumber(left) && isNumber(right) {
+		res, ok := compareNumbers(left, right)
+		return ok && res == 0
+	}
+	switch l := left.(type) {
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	}
+	return left == right
+}
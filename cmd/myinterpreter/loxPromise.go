@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LoxPromise is a value that is resolved later, returned by async
+// functions, sleep() and promise(). Callbacks waiting for it run
+// on the event loop after it is resolved.
+type LoxPromise struct {
+	loop *EventLoop
+	// only promises created by promise() can be resolved by the script,
+	// the others are resolved by the async function or timer behind them
+	resolvable bool
+	mu         sync.Mutex
+	resolved   bool
+	value      any
+	callbacks  []func(any)
+}
+
+func NewLoxPromise(loop *EventLoop) *LoxPromise {
+	return &LoxPromise{loop: loop}
+}
+
+func (p *LoxPromise) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved {
+		return "<promise resolved>"
+	}
+	return "<promise pending>"
+}
+
+func (p *LoxPromise) isResolved() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resolved
+}
+
+// resolve returns false when the promise is already resolved.
+// Resolving with another promise resolves with its value once it is known.
+func (p *LoxPromise) resolve(value any) bool {
+	if other, ok := value.(*LoxPromise); ok {
+		other.onResolve(func(value any) { p.resolve(value) })
+		return true
+	}
+	p.mu.Lock()
+	if p.resolved {
+		p.mu.Unlock()
+		return false
+	}
+	p.resolved, p.value = true, value
+	callbacks := p.callbacks
+	p.callbacks = nil
+	p.mu.Unlock()
+	for _, callback := range callbacks {
+		p.loop.enqueue(func() { callback(value) })
+	}
+	return true
+}
+
+// onResolve schedules callback on the event loop once the promise is resolved.
+func (p *LoxPromise) onResolve(callback func(any)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved {
+		value := p.value
+		p.loop.enqueue(func() { callback(value) })
+		return
+	}
+	p.callbacks = append(p.callbacks, callback)
+}
+
+// coroutine runs the body of an async function on its own goroutine,
+// the goroutine only runs while the event loop waits for it to suspend.
+type coroutine struct {
+	resume    chan any
+	suspended chan struct{}
+	// panic that ended the body, wait raises it where the body was resumed from
+	failure any
+}
+
+// wait blocks until the body suspends or finishes.
+func (co *coroutine) wait() {
+	<-co.suspended
+	if co.failure != nil {
+		panic(co.failure)
+	}
+}
+
+// startAsync runs the body of an async function until the first await
+// and returns a promise of its result.
+func (i Interpreter) startAsync(lf *LoxFunction, funState *State) *LoxPromise {
+	promise := NewLoxPromise(i.loop)
+	co := &coroutine{resume: make(chan any), suspended: make(chan struct{})}
+	i.coroutine, i.generator = co, nil
+	go func() {
+		defer func() {
+			co.failure = recover()
+			co.suspended <- struct{}{}
+		}()
+		promise.resolve(lf.runBody(i, funState))
+	}()
+	co.wait()
+	return promise
+}
+
+// await suspends the coroutine, the event loop resumes it with the value.
+func (co *coroutine) await(promise *LoxPromise, keyword Token) any {
+	promise.loop.suspend(co, keyword)
+	promise.onResolve(func(value any) {
+		promise.loop.resume(co)
+		co.resume <- value
+		co.wait()
+	})
+	co.suspended <- struct{}{}
+	return <-co.resume
+}
+
+func (i Interpreter) visitAwaitExpr(expr *AwaitExpr) any {
+	value := i.evaluate(expr.value)
+	promise, ok := value.(*LoxPromise)
+	if !ok {
+		return value
+	}
+	if i.coroutine != nil {
+		return i.coroutine.await(promise, expr.keyword)
+	}
+	for !promise.isResolved() {
+		if !i.loop.step() {
+			i.error(expr.keyword, "Awaited promise is never resolved, nothing is left to run")
+		}
+	}
+	return promise.value
+}
+
+// runLoop runs the event loop after the script. Async functions still
+// suspended when it is done are never resumed, like a top level await.
+func (i Interpreter) runLoop() {
+	i.loop.run()
+	if await, stalled := i.loop.stalled(); stalled {
+		i.error(await, "Awaited promise is never resolved, nothing is left to run")
+	}
+}
+
+func (i Interpreter) promiseMethod(receiver *LoxPromise, name Token) any {
+	method, exist := promiseMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on promise", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+// loopInterpreter is the copy of the interpreter used for callbacks run by the event loop.
+func (i Interpreter) loopInterpreter() Interpreter {
+	i.coroutine, i.generator = nil, nil
+	return i
+}
+
+var promiseMethods = map[string]nativeMethod{
+	// then(fn) returns a promise of fn(value)
+	"then": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		promise := m.receiver.(*LoxPromise)
+		if _, ok := args[0].(LoxCallable); !ok {
+			i.error(m.name, "Argument should be a function")
+		}
+		next := NewLoxPromise(promise.loop)
+		li := i.loopInterpreter()
+		promise.onResolve(func(value any) {
+			next.resolve(li.callValue(m.name, args[0], []any{value}))
+		})
+		return next
+	}},
+	"resolve": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		promise := m.receiver.(*LoxPromise)
+		if !promise.resolvable {
+			i.error(m.name, "Only promises created by promise() can be resolved")
+		}
+		if !promise.resolve(args[0]) {
+			i.error(m.name, "Promise is already resolved")
+		}
+		return nil
+	}},
+	"isResolved": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return m.receiver.(*LoxPromise).isResolved()
+	}},
+}
+
+var eventLoopNatives = map[string]*NativeFunction{
+	// promise() creates a pending promise, resolve it with its resolve(value) method
+	"promise": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		promise := NewLoxPromise(i.loop)
+		promise.resolvable = true
+		return promise
+	}),
+	// sleep(ms) returns a promise resolved after ms milliseconds
+	"sleep": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		promise := NewLoxPromise(i.loop)
+		i.loop.addTimer(i.millisecondsArg(args, 0), 0, func() { promise.resolve(nil) })
+		return promise
+	}),
+	// setTimeout(fn, ms) calls fn once, it returns timer id for clearTimer
+	"setTimeout": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		li, token := i.loopInterpreter(), i.callToken
+		return i.loop.addTimer(i.millisecondsArg(args, 1), 0, func() { li.callValue(token, args[0], []any{}) })
+	}),
+	// setInterval(fn, ms) calls fn every ms milliseconds until clearTimer(id)
+	"setInterval": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		interval := i.millisecondsArg(args, 1)
+		if interval == 0 {
+			i.error(i.callToken, "Interval should be positive")
+		}
+		li, token := i.loopInterpreter(), i.callToken
+		return i.loop.addTimer(interval, interval, func() { li.callValue(token, args[0], []any{}) })
+	}),
+	"clearTimer": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		id, ok := args[0].(int64)
+		if !ok {
+			i.error(i.callToken, "Argument should be a timer id")
+		}
+		i.loop.cancelTimer(id)
+		return nil
+	}),
+	// now() is the number of milliseconds since the start, it follows the virtual clock
+	"now": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		return i.loop.elapsed().Milliseconds()
+	}),
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// LoxRegex is a compiled pattern returned by regex(pattern).
+type LoxRegex struct {
+	re *regexp.Regexp
+}
+
+func NewLoxRegex(re *regexp.Regexp) *LoxRegex {
+	return &LoxRegex{re: re}
+}
+
+func (r *LoxRegex) String() string {
+	return fmt.Sprintf("<regex %v>", r.re.String())
+}
+
+// Compiled patterns are cached, so calling regex() inside a loop is cheap.
+// The cache keeps the regexCacheSize most recently used patterns.
+const regexCacheSize = 256
+
+var (
+	regexCache      = make(map[string]*list.Element)
+	regexCacheOrder = list.New()
+	regexCacheMutex sync.Mutex
+)
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCacheMutex.Lock()
+	defer regexCacheMutex.Unlock()
+	if element, exist := regexCache[pattern]; exist {
+		regexCacheOrder.MoveToFront(element)
+		return element.Value.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache[pattern] = regexCacheOrder.PushFront(re)
+	if regexCacheOrder.Len() > regexCacheSize {
+		oldest := regexCacheOrder.Remove(regexCacheOrder.Back()).(*regexp.Regexp)
+		delete(regexCache, oldest.String())
+	}
+	return re, nil
+}
+
+// Regex matches are instances of Match with fields
+// text, start and end (in code points), groups (array of captured
+// groups, nil for the ones that didn't participate) and named
+// (an instance with a field per named group).
+var regexMatchClass = NewLoxClass("Match", nil, make(map[string]*LoxFunction))
+
+func (r *LoxRegex) newMatch(s string, loc []int) *LoxInstance {
+	match := NewLoxInstance(regexMatchClass)
+	match.fields["text"] = s[loc[0]:loc[1]]
+	match.fields["start"] = int64(utf8.RuneCountInString(s[:loc[0]]))
+	match.fields["end"] = int64(utf8.RuneCountInString(s[:loc[1]]))
+	groups := make([]any, 0, r.re.NumSubexp())
+	named := NewLoxInstance(jsonObjectClass)
+	for idx, name := range r.re.SubexpNames()[1:] {
+		var group any = nil
+		if start := loc[2*idx+2]; start >= 0 {
+			group = s[start:loc[2*idx+3]]
+		}
+		groups = append(groups, group)
+		if name != "" {
+			named.fields[name] = group
+		}
+	}
+	match.fields["groups"] = NewLoxArray(groups)
+	match.fields["named"] = named
+	return match
+}
+
+func (i Interpreter) regexMethod(receiver *LoxRegex, name Token) any {
+	if name.Lexeme == "pattern" {
+		return receiver.re.String()
+	}
+	method, exist := regexMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on regex", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+// regex(pattern) uses Go regexp syntax, invalid patterns return an error value
+var regexNative = NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+	re, err := compileRegex(i.stringArg(args, 0))
+	if err != nil {
+		return NewLoxError(err.Error())
+	}
+	return NewLoxRegex(re)
+})
+
+var regexMethods = map[string]nativeMethod{
+	// match reports whether the pattern matches anywhere in the string
+	"match": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		return m.receiver.(*LoxRegex).re.MatchString(m.stringArg(i, args, 0))
+	}},
+	// find returns the first match or nil
+	"find": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		r := m.receiver.(*LoxRegex)
+		s := m.stringArg(i, args, 0)
+		loc := r.re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return nil
+		}
+		return r.newMatch(s, loc)
+	}},
+	"findAll": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		r := m.receiver.(*LoxRegex)
+		s := m.stringArg(i, args, 0)
+		matches := make([]any, 0)
+		for _, loc := range r.re.FindAllStringSubmatchIndex(s, -1) {
+			matches = append(matches, r.newMatch(s, loc))
+		}
+		return NewLoxArray(matches)
+	}},
+	// replace(s, replacement) expands $1 and ${name} in the replacement string,
+	// a callable replacement is called with the match and should return a string
+	"replace": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		r := m.receiver.(*LoxRegex)
+		s := m.stringArg(i, args, 0)
+		if replacement, ok := args[1].(string); ok {
+			return r.re.ReplaceAllString(s, replacement)
+		}
+		var res strings.Builder
+		last := 0
+		for _, loc := range r.re.FindAllStringSubmatchIndex(s, -1) {
+			replacement, ok := i.callValue(m.name, args[1], []any{r.newMatch(s, loc)}).(string)
+			if !ok {
+				i.error(m.name, "Replacement function should return a string")
+			}
+			res.WriteString(s[last:loc[0]])
+			res.WriteString(replacement)
+			last = loc[1]
+		}
+		res.WriteString(s[last:])
+		return res.String()
+	}},
+	"split": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		parts := m.receiver.(*LoxRegex).re.Split(m.stringArg(i, args, 0), -1)
+		res := make([]any, len(parts))
+		for idx, part := range parts {
+			res[idx] = part
+		}
+		return NewLoxArray(res)
+	}},
+}
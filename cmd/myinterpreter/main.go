@@ -8,11 +8,22 @@ import (
 
 func main() {
 	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh tokenize <filename>")
+		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh tokenize|parse|evaluate|run [--virtual-clock] <filename> [args...]")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
+	// --virtual-clock makes timers fire without waiting, for deterministic tests,
+	// LOX_VIRTUAL_CLOCK=1 does the same for scripts run by other tools
+	virtualClock := os.Getenv("LOX_VIRTUAL_CLOCK") != ""
+	if command == "run" && os.Args[2] == "--virtual-clock" {
+		virtualClock = true
+		os.Args = append(os.Args[:2], os.Args[3:]...)
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh run [--virtual-clock] <filename> [args...]")
+			os.Exit(1)
+		}
+	}
 
 	if command != "tokenize" && command != "parse" && command != "evaluate" && command != "run" {
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
@@ -62,6 +73,7 @@ func main() {
 		}
 		os.Exit(parser.exitCode)
 	} else if command == "evaluate" {
+		defer exitOnRuntimeError()
 		parser := NewParser(tokens)
 		exprs := parser.parseExprs()
 		interp := NewInterpreter(parser)
@@ -70,20 +82,35 @@ func main() {
 			res = append(res, expr.accept(interp))
 		}
 		for _, v := range res {
-			if v == nil {
-				fmt.Println("nil")
-			} else {
-				fmt.Println(v)
-			}
+			fmt.Println(interp.stringify(v))
 		}
 	} else if command == "run" {
+		defer exitOnRuntimeError()
 		parser := NewParser(tokens)
 		interp := NewInterpreter(parser)
+		interp.setArgs(os.Args[3:])
 		resolver := NewResolver(interp)
 		stmts := parser.parseStmts()
 		resolver.resolveStmts(stmts)
+		interp.loop.virtual = virtualClock
 		for _, stmt := range stmts {
 			interp.execute(stmt)
 		}
+		interp.runLoop()
+		if !interp.tasks.report() {
+			os.Exit(70)
+		}
+	}
+}
+
+// exitOnRuntimeError reports the runtime error that ended the main task.
+func exitOnRuntimeError() {
+	if err := recover(); err != nil {
+		failure, ok := err.(runtimeError)
+		if !ok {
+			panic(err)
+		}
+		fmt.Fprintln(os.Stderr, failure.message)
+		os.Exit(70)
 	}
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+func NewMathModule() *LoxModule {
+	functions := map[string]nativeMethod{
+		"sqrt":  floatFunction(math.Sqrt),
+		"cbrt":  floatFunction(math.Cbrt),
+		"exp":   floatFunction(math.Exp),
+		"log":   floatFunction(math.Log),
+		"log2":  floatFunction(math.Log2),
+		"log10": floatFunction(math.Log10),
+		"sin":   floatFunction(math.Sin),
+		"cos":   floatFunction(math.Cos),
+		"tan":   floatFunction(math.Tan),
+		"asin":  floatFunction(math.Asin),
+		"acos":  floatFunction(math.Acos),
+		"atan":  floatFunction(math.Atan),
+		"sinh":  floatFunction(math.Sinh),
+		"cosh":  floatFunction(math.Cosh),
+		"tanh":  floatFunction(math.Tanh),
+		"asinh": floatFunction(math.Asinh),
+		"acosh": floatFunction(math.Acosh),
+		"atanh": floatFunction(math.Atanh),
+		"atan2": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+			return math.Atan2(m.numberArg(i, args, 0), m.numberArg(i, args, 1))
+		}},
+		// pow follows the rules of `**`
+		"pow": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+			m.numberArg(i, args, 0)
+			m.numberArg(i, args, 1)
+			return i.arithmetic(*NewToken("**", STAR_STAR, nil, m.name.Line), args[0], args[1])
+		}},
+		"abs": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+			m.numberArg(i, args, 0)
+			switch arg := args[0].(type) {
+			case int64:
+				if arg == math.MinInt64 {
+					return new(big.Int).Neg(big.NewInt(arg))
+				} else if arg < 0 {
+					return -arg
+				}
+				return arg
+			case *big.Int:
+				return new(big.Int).Abs(arg)
+			case *LoxDecimal:
+				return NewLoxDecimal(new(big.Int).Abs(arg.unscaled), arg.scale)
+			}
+			return math.Abs(args[0].(float64))
+		}},
+		"ceil":  roundingFunction(math.Ceil, ROUND_CEILING),
+		"trunc": roundingFunction(math.Trunc, ROUND_DOWN),
+		// round(x) rounds half away from zero to an integer,
+		// round(x, places) keeps the type of x.
+		"round": {1, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+			if len(args) == 1 {
+				return roundingFunction(math.Round, ROUND_HALF_UP).fn(m, i, args)
+			}
+			places := m.intArg(i, args, 1)
+			if places < 0 {
+				i.error(m.name, "Number of places can't be negative")
+			}
+			switch arg := args[0].(type) {
+			case int64, *big.Int:
+				return arg
+			case *LoxDecimal:
+				return arg.Round(int(places), ROUND_HALF_UP)
+			}
+			scale := math.Pow(10, float64(places))
+			return math.Round(m.numberArg(i, args, 0)*scale) / scale
+		}},
+		"min": {1, -1, func(m *NativeMethod, i Interpreter, args []any) any {
+			return m.extremum(i, args, -1)
+		}},
+		"max": {1, -1, func(m *NativeMethod, i Interpreter, args []any) any {
+			return m.extremum(i, args, 1)
+		}},
+		"isNan": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+			return math.IsNaN(m.numberArg(i, args, 0))
+		}},
+		"isFinite": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+			value := m.numberArg(i, args, 0)
+			return !math.IsNaN(value) && !math.IsInf(value, 0)
+		}},
+	}
+	constants := map[string]any{
+		"pi":  math.Pi,
+		"e":   math.E,
+		"inf": math.Inf(1),
+		"nan": math.NaN(),
+	}
+	return NewLoxModule("math", functions, constants)
+}
+
+// numberArg accepts any lox number and returns it as float.
+func (m *NativeMethod) numberArg(i Interpreter, args []any, idx int) float64 {
+	if !isNumber(args[idx]) {
+		i.error(m.name, fmt.Sprintf("Argument %v should be a number", idx+1))
+	}
+	return toFloat(args[idx])
+}
+
+func floatFunction(fn func(float64) float64) nativeMethod {
+	return nativeMethod{1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		return fn(m.numberArg(i, args, 0))
+	}}
+}
+
+// roundingFunction returns integers, integer arguments are returned as is.
+func roundingFunction(fn func(float64) float64, mode RoundingMode) nativeMethod {
+	return nativeMethod{1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		value := m.numberArg(i, args, 0)
+		switch arg := args[0].(type) {
+		case int64, *big.Int:
+			return arg
+		case *LoxDecimal:
+			return normalizeInt(divRound(arg.unscaled, pow10(arg.scale), mode))
+		}
+		return floatToInt(fn(value))
+	}}
+}
+
+// floatToInt converts an integral float to int64 or bigint,
+// infinities and NaN stay floats.
+func floatToInt(value float64) any {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	if value >= math.MinInt64 && value < math.MaxInt64 {
+		return int64(value)
+	}
+	res, _ := big.NewFloat(value).Int(nil)
+	return res
+}
+
+// extremum returns the smallest (sign -1) or the biggest (sign 1) argument.
+func (m *NativeMethod) extremum(i Interpreter, args []any, sign int) any {
+	res := args[0]
+	for idx := range args {
+		if m.numberArg(i, args, idx); math.IsNaN(toFloat(args[idx])) {
+			return math.NaN()
+		}
+		if cmp, _ := compareNumbers(args[idx], res); cmp == sign {
+			res = args[idx]
+		}
+	}
+	return res
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"time"
+	"unicode/utf8"
 )
 
 type nativeFnStringImpl struct{}
@@ -11,6 +14,43 @@ func (s nativeFnStringImpl) String() string {
 	return "<native fn>"
 }
 
+// NativeFunction is a builtin implemented by a plain go function,
+// used for the bigger groups of natives (file system, ...).
+type NativeFunction struct {
+	nativeFnStringImpl
+	minArity int
+	maxArity int
+	fn       func(i Interpreter, args []any) any
+}
+
+func NewNativeFunction(minArity, maxArity int, fn func(i Interpreter, args []any) any) *NativeFunction {
+	return &NativeFunction{
+		minArity: minArity,
+		maxArity: maxArity,
+		fn:       fn,
+	}
+}
+
+func (f *NativeFunction) call(i Interpreter, args []any) any {
+	return f.fn(i, args)
+}
+
+func (f *NativeFunction) arity() int {
+	return f.minArity
+}
+
+func (f *NativeFunction) arityRange() (int, int) {
+	return f.minArity, f.maxArity
+}
+
+func (i Interpreter) stringArg(args []any, idx int) string {
+	arg, ok := args[idx].(string)
+	if !ok {
+		i.error(i.callToken, fmt.Sprintf("Argument %v should be a string", idx+1))
+	}
+	return arg
+}
+
 type LoxTime struct {
 	nativeFnStringImpl
 }
@@ -29,10 +69,18 @@ type Floor struct {
 
 func (f Floor) call(i Interpreter, args []any) any {
 	switch arg := args[0].(type) {
+	case int64, *big.Int:
+		return arg
+	case *LoxDecimal:
+		return normalizeInt(divRound(arg.unscaled, pow10(arg.scale), ROUND_FLOOR))
 	case float64:
-		return float64(int64(arg))
+		floored := math.Floor(arg)
+		if floored < math.MinInt64 || floored >= math.MaxInt64 || math.IsNaN(floored) {
+			return floored
+		}
+		return int64(floored)
 	default:
-		i.error(i.parser.getCurrent(), "Argument should be a number")
+		i.error(i.callToken, "Argument should be a number")
 	}
 	panic("unreachable")
 }
@@ -46,7 +94,7 @@ type Str struct {
 }
 
 func (s Str) call(i Interpreter, args []any) any {
-	return fmt.Sprintf("%v", args[0])
+	return i.stringify(args[0])
 }
 
 func (s Str) arity() int {
@@ -59,10 +107,12 @@ type Len struct {
 
 func (l Len) call(i Interpreter, args []any) any {
 	switch arr := args[0].(type) {
-	case []any:
-		return float64(len(arr))
+	case *LoxArray:
+		return arr.length()
+	case string:
+		return int64(utf8.RuneCountInString(arr))
 	default:
-		i.error(i.parser.getCurrent(), "Only arrays have len")
+		i.error(i.callToken, "Only arrays and strings have len")
 	}
 	panic("unreachable")
 }
@@ -76,10 +126,97 @@ type PrintLine struct {
 }
 
 func (p PrintLine) call(i Interpreter, args []any) any {
-	fmt.Println(args[0])
+	fmt.Println(i.stringify(args[0]))
 	return nil
 }
 
 func (p PrintLine) arity() int {
 	return 1
 }
+
+type BigInt struct {
+	nativeFnStringImpl
+}
+
+func (b BigInt) call(i Interpreter, args []any) any {
+	switch arg := args[0].(type) {
+	case int64:
+		return big.NewInt(arg)
+	case *big.Int:
+		return arg
+	case *LoxDecimal:
+		return new(big.Int).Quo(arg.unscaled, pow10(arg.scale))
+	case float64:
+		if math.IsInf(arg, 0) || math.IsNaN(arg) {
+			i.error(i.callToken, "Can't convert inf or nan to bigint")
+		}
+		res, _ := big.NewFloat(math.Trunc(arg)).Int(nil)
+		return res
+	case string:
+		res, ok := new(big.Int).SetString(arg, 10)
+		if !ok {
+			i.error(i.callToken, fmt.Sprintf("Can't convert '%v' to bigint", arg))
+		}
+		return res
+	default:
+		i.error(i.callToken, "Argument should be a number or a string")
+	}
+	panic("unreachable")
+}
+
+func (b BigInt) arity() int {
+	return 1
+}
+
+type Decimal struct {
+	nativeFnStringImpl
+}
+
+func (d Decimal) call(i Interpreter, args []any) any {
+	res, err := toDecimal(args[0])
+	if err != nil {
+		i.error(i.callToken, err.Error())
+	}
+	return res
+}
+
+func (d Decimal) arity() int {
+	return 1
+}
+
+// decimalContext(precision, rounding) configures decimal division,
+// rounding is one of the keys of roundingModes.
+type SetDecimalContext struct {
+	nativeFnStringImpl
+}
+
+func (d SetDecimalContext) call(i Interpreter, args []any) any {
+	precision, ok := args[0].(int64)
+	if !ok || precision < 0 {
+		i.error(i.callToken, "Precision should be a non negative integer")
+	}
+	name, ok := args[1].(string)
+	rounding, exist := roundingModes[name]
+	if !ok || !exist {
+		i.error(i.callToken, "Unknown rounding mode")
+	}
+	i.decimalContext.Store(&DecimalContext{precision: int(precision), rounding: rounding})
+	return nil
+}
+
+func (d SetDecimalContext) arity() int {
+	return 2
+}
+
+type IsError struct {
+	nativeFnStringImpl
+}
+
+func (e IsError) call(i Interpreter, args []any) any {
+	_, ok := args[0].(*LoxError)
+	return ok
+}
+
+func (e IsError) arity() int {
+	return 1
+}
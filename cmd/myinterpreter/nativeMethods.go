@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type nativeMethod struct {
+	minArity int
+	maxArity int
+	fn       func(m *NativeMethod, i Interpreter, args []any) any
+}
+
+// NativeMethod is a builtin method bound to its receiver,
+// returned by GetExpr on values that are not instances (strings, arrays).
+type NativeMethod struct {
+	name     Token
+	receiver any
+	method   nativeMethod
+}
+
+func NewNativeMethod(name Token, receiver any, method nativeMethod) *NativeMethod {
+	return &NativeMethod{
+		name:     name,
+		receiver: receiver,
+		method:   method,
+	}
+}
+
+func (m *NativeMethod) arity() int {
+	return m.method.minArity
+}
+
+func (m *NativeMethod) arityRange() (int, int) {
+	return m.method.minArity, m.method.maxArity
+}
+
+func (m *NativeMethod) call(i Interpreter, args []any) any {
+	return m.method.fn(m, i, args)
+}
+
+func (m *NativeMethod) String() string {
+	return fmt.Sprintf("<native method %v>", m.name.Lexeme)
+}
+
+func (m *NativeMethod) stringArg(i Interpreter, args []any, idx int) string {
+	arg, ok := args[idx].(string)
+	if !ok {
+		i.error(m.name, fmt.Sprintf("Argument %v should be a string", idx+1))
+	}
+	return arg
+}
+
+func (m *NativeMethod) intArg(i Interpreter, args []any, idx int) int64 {
+	arg, ok := args[idx].(int64)
+	if !ok {
+		i.error(m.name, fmt.Sprintf("Argument %v should be an integer", idx+1))
+	}
+	return arg
+}
+
+func (i Interpreter) stringMethod(receiver string, name Token) any {
+	method, exist := stringMethods[name.Lexeme]
+	if !exist {
+		i.error(name, fmt.Sprintf("Undefined method '%v' on string", name.Lexeme))
+	}
+	return NewNativeMethod(name, receiver, method)
+}
+
+var stringMethods = map[string]nativeMethod{
+	"split": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		parts := strings.Split(m.receiver.(string), m.stringArg(i, args, 0))
+		res := make([]any, len(parts))
+		for idx, part := range parts {
+			res[idx] = part
+		}
+		return NewLoxArray(res)
+	}},
+	"join": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		array, ok := args[0].(*LoxArray)
+		if !ok {
+			i.error(m.name, "Argument should be an array")
+		}
+		elements := array.snapshot()
+		parts := make([]string, len(elements))
+		for idx, element := range elements {
+			part, ok := element.(string)
+			if !ok {
+				i.error(m.name, fmt.Sprintf("Element %v of joined array is not a string", idx))
+			}
+			parts[idx] = part
+		}
+		return strings.Join(parts, m.receiver.(string))
+	}},
+	"trim": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.TrimSpace(m.receiver.(string))
+	}},
+	"upper": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.ToUpper(m.receiver.(string))
+	}},
+	"lower": {0, 0, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.ToLower(m.receiver.(string))
+	}},
+	"replace": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.ReplaceAll(m.receiver.(string), m.stringArg(i, args, 0), m.stringArg(i, args, 1))
+	}},
+	"contains": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.Contains(m.receiver.(string), m.stringArg(i, args, 0))
+	}},
+	"startsWith": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.HasPrefix(m.receiver.(string), m.stringArg(i, args, 0))
+	}},
+	"endsWith": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		return strings.HasSuffix(m.receiver.(string), m.stringArg(i, args, 0))
+	}},
+	// indexOf returns index in code points, like subscription uses
+	"indexOf": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		receiver := m.receiver.(string)
+		idx := strings.Index(receiver, m.stringArg(i, args, 0))
+		if idx < 0 {
+			return int64(-1)
+		}
+		return int64(utf8.RuneCountInString(receiver[:idx]))
+	}},
+	"repeat": {1, 1, func(m *NativeMethod, i Interpreter, args []any) any {
+		count := m.intArg(i, args, 0)
+		if count < 0 {
+			i.error(m.name, "Repeat count can't be negative")
+		}
+		return strings.Repeat(m.receiver.(string), int(count))
+	}},
+	"padLeft": {2, 2, func(m *NativeMethod, i Interpreter, args []any) any {
+		receiver := m.receiver.(string)
+		width := m.intArg(i, args, 0)
+		pad := []rune(m.stringArg(i, args, 1))
+		if len(pad) == 0 {
+			i.error(m.name, "Padding can't be empty")
+		}
+		missing := int(width) - utf8.RuneCountInString(receiver)
+		if missing <= 0 {
+			return receiver
+		}
+		padding := make([]rune, missing)
+		for idx := range padding {
+			padding[idx] = pad[idx%len(pad)]
+		}
+		return string(padding) + receiver
+	}},
+}
@@ -43,16 +43,59 @@ func (p *Parser) parseStmts() []Stmt {
 }
 
 func (p *Parser) declaration() Stmt {
-	if p.match(CLASS) {
+	if p.check(AT) {
+		return p.decoratedDeclaration()
+	} else if p.match(CLASS) {
 		return p.classDeclaration()
 	} else if p.match(FUN) {
 		return p.funStatement("function")
+	} else if p.match(ASYNC) {
+		return p.asyncFunction()
 	} else if p.match(VAR) {
 		return p.varStatement()
+	} else if p.match(CONST) {
+		return p.constStatement()
 	}
 	return p.statement()
 }
 
+// decorators parses `@expr` before a function, method or class declaration.
+func (p *Parser) decorators() []Expr {
+	decorators := make([]Expr, 0)
+	for p.match(AT) {
+		decorators = append(decorators, p.call())
+	}
+	return decorators
+}
+
+func (p *Parser) decoratedDeclaration() Stmt {
+	decorators := p.decorators()
+	if p.match(CLASS) {
+		cls := p.classDeclaration().(*Class)
+		cls.decorators = decorators
+		return cls
+	} else if p.match(FUN) {
+		fn := p.funStatement("function").(*Function)
+		fn.decorators = decorators
+		return fn
+	} else if p.match(ASYNC) {
+		fn := p.asyncFunction().(*Function)
+		fn.decorators = decorators
+		return fn
+	}
+	p.error("Expect function or class after decorator")
+	return nil
+}
+
+func (p *Parser) asyncFunction() Stmt {
+	if !p.match(FUN) {
+		p.error("Expect 'fun' after 'async'")
+	}
+	fn := p.funStatement("function").(*Function)
+	fn.isAsync = true
+	return fn
+}
+
 func (p *Parser) statement() Stmt {
 	if p.match(FOR) {
 		return p.forStatement()
@@ -101,10 +144,20 @@ func (p *Parser) classDeclaration() Stmt {
 	}
 	methods := make([]*Function, 0)
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		decorators := p.decorators()
+		if len(decorators) > 0 && p.getCurrent().Lexeme == "init" {
+			p.error("Initializer can't be decorated")
+		}
+		isAsync := p.match(ASYNC)
+		if isAsync && p.getCurrent().Lexeme == "init" {
+			p.error("Initializer can't be async")
+		}
 		method, ok := p.funStatement("method").(*Function)
 		if !ok {
 			panic("never")
 		}
+		method.decorators = decorators
+		method.isAsync = isAsync
 		methods = append(methods, method)
 	}
 	if !p.match(RIGHT_BRACE) {
@@ -125,30 +178,43 @@ func (p *Parser) funStatement(kind string) Stmt {
 		p.error("Expect '(' before condition expression")
 	}
 
-	if !p.check(RIGHT_PAREN) {
+	defaults := make([]Expr, 0)
+	var rest *Token = nil
+	for !p.check(RIGHT_PAREN) {
+		if len(parameters) >= 255 {
+			p.error(fmt.Sprintf("too many arguments %v, expect no more than 255", len(parameters)))
+		}
+		isRest := p.match(ELLIPSIS)
 		param := p.getCurrent()
 		p.currentIndex++
+		if param.Token != IDENTIFIER {
+			p.error("Expect parametr name")
+		}
+		if isRest {
+			// rest parameter has to be the last one
+			rest = &param
+			break
+		}
+		var value Expr = nil
+		if p.match(EQUAL) {
+			value = p.nextExpr()
+		} else if len(defaults) > 0 && defaults[len(defaults)-1] != nil {
+			p.error("Parameter without default value can't follow parameters with defaults")
+		}
 		parameters = append(parameters, param)
-		for p.match(COMMA) {
-			if len(parameters) > 255 {
-				p.error(fmt.Sprintf("too many arguments %v, expect no more than 255", len(parameters)))
-			}
-			param := p.getCurrent()
-			p.currentIndex++
-			if param.Token != IDENTIFIER {
-				p.error("Expect parametr name")
-			}
-			parameters = append(parameters, param)
+		defaults = append(defaults, value)
+		if !p.match(COMMA) {
+			break
 		}
 	}
 	if !p.match(RIGHT_PAREN) {
-		p.error("Expect '(' after parameters")
+		p.error("Expect ')' after parameters")
 	}
 	if !p.match(LEFT_BRACE) {
 		p.error("Expect '{' before function body")
 	}
 	body := p.blockStatement()
-	return NewFunction(name, parameters, body.(*Block))
+	return NewFunction(name, parameters, defaults, rest, body.(*Block))
 }
 
 func (p *Parser) returnStatement() Stmt {
@@ -165,6 +231,9 @@ func (p *Parser) returnStatement() Stmt {
 }
 
 func (p *Parser) varStatement() Stmt {
+	if p.check(LEFT_SQUARE_BRACKET) || p.check(LEFT_BRACE) {
+		return p.destructureStatement(false)
+	}
 	varName := p.incrIndex()
 	var varValue Expr = nil
 	if varName.Token != IDENTIFIER {
@@ -177,7 +246,68 @@ func (p *Parser) varStatement() Stmt {
 		p.error("Expect ';' after variable declaration")
 	}
 	p.incrIndex()
-	return NewVar(varName, varValue)
+	return NewVar(varName, varValue, false)
+}
+
+func (p *Parser) constStatement() Stmt {
+	if p.check(LEFT_SQUARE_BRACKET) || p.check(LEFT_BRACE) {
+		return p.destructureStatement(true)
+	}
+	constName := p.incrIndex()
+	if constName.Token != IDENTIFIER {
+		p.error("Expect constant name.")
+	}
+	if !p.match(EQUAL) {
+		p.error("Expect '=' after constant name, constants must be initialized")
+	}
+	constValue := p.nextExpr()
+	if !p.match(SEMICOLON) {
+		p.error("Expect ';' after constant declaration")
+	}
+	return NewVar(constName, constValue, true)
+}
+
+func (p *Parser) destructureStatement(isConst bool) Stmt {
+	keyword := p.incrIndex()
+	isObject := keyword.Token == LEFT_BRACE
+	closing, closingLexeme := RIGHT_SQUARE_BRACKET, "]"
+	if isObject {
+		closing, closingLexeme = RIGHT_BRACE, "}"
+	}
+	names := make([]Token, 0)
+	var rest *Token = nil
+	for !p.check(closing) && !p.isAtEnd() {
+		if !isObject && p.match(ELLIPSIS) {
+			restName := p.incrIndex()
+			rest = &restName
+			if restName.Token != IDENTIFIER {
+				p.error("Expect name after '...'")
+			}
+			if !p.check(closing) {
+				p.error("Rest element must be last")
+			}
+			break
+		}
+		name := p.incrIndex()
+		if name.Token != IDENTIFIER {
+			p.error("Expect variable name in destructuring pattern")
+		}
+		names = append(names, name)
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	if !p.match(closing) {
+		p.error(fmt.Sprintf("Expect '%v' after destructuring pattern", closingLexeme))
+	}
+	if !p.match(EQUAL) {
+		p.error("Expect '=' after destructuring pattern")
+	}
+	value := p.nextExpr()
+	if !p.match(SEMICOLON) {
+		p.error("Expect ';' after variable declaration")
+	}
+	return NewDestructure(keyword, names, rest, isObject, value, isConst)
 }
 
 func (p *Parser) printStatement() Stmt {
@@ -301,6 +431,10 @@ func (p *Parser) match(tts ...TokenType) bool {
 	return false
 }
 
+func (p *Parser) checkNext(t TokenType) bool {
+	return p.currentIndex+1 < len(p.tokens) && p.tokens[p.currentIndex+1].Token == t
+}
+
 func (p *Parser) getCurrent() Token {
 	return p.tokens[p.currentIndex]
 }
@@ -347,17 +481,26 @@ func (p *Parser) literalExpr() Expr {
 	return nil
 }
 
+// spreadableExpr is an expression that can be prefixed with '...',
+// used for call arguments and array elements.
+func (p *Parser) spreadableExpr() Expr {
+	if p.match(ELLIPSIS) {
+		return NewSpreadExpr(p.getPrev(), p.nextExpr())
+	}
+	return p.nextExpr()
+}
+
 func (p *Parser) arrayLiteral() Expr {
 	elements := make([]Expr, 0)
 	if p.check(RIGHT_SQUARE_BRACKET) {
 		p.currentIndex++
 		return NewArrayDeclExpr(elements)
 	} else {
-		elements = append(elements, p.nextExpr())
+		elements = append(elements, p.spreadableExpr())
 	}
 	for p.match(COMMA) {
 		if p.getCurrent().Token != RIGHT_SQUARE_BRACKET {
-			elements = append(elements, p.nextExpr())
+			elements = append(elements, p.spreadableExpr())
 		}
 	}
 	if !p.check(RIGHT_SQUARE_BRACKET) {
@@ -383,12 +526,30 @@ func (p *Parser) group() Expr {
 	return p.literalExpr()
 }
 
+// finishCall parses positional arguments followed by named
+// arguments in form name: value.
 func (p *Parser) finishCall(callee Expr) Expr {
 	arguments := make([]Expr, 0)
+	names := make([]*Token, 0)
 	if !p.check(RIGHT_PAREN) {
-		arguments = append(arguments, p.nextExpr())
-		for p.match(COMMA) {
-			arguments = append(arguments, p.nextExpr())
+		for {
+			var name *Token = nil
+			if p.check(IDENTIFIER) && p.checkNext(COLON) {
+				token := p.incrIndex()
+				name = &token
+				p.incrIndex()
+			} else if len(names) > 0 && names[len(names)-1] != nil {
+				p.error("Positional argument can't follow named arguments")
+			}
+			if name != nil {
+				arguments = append(arguments, p.nextExpr())
+			} else {
+				arguments = append(arguments, p.spreadableExpr())
+			}
+			names = append(names, name)
+			if !p.match(COMMA) {
+				break
+			}
 		}
 		if len(arguments) > 255 {
 			p.error(fmt.Sprintf("too many arguments %v, expect no more than 255", len(arguments)))
@@ -400,11 +561,18 @@ func (p *Parser) finishCall(callee Expr) Expr {
 		p.error("expected ')' after arguments")
 	}
 	p.currentIndex++
-	return NewCallExpr(p.getPrev(), callee, arguments)
+	return NewCallExpr(p.getPrev(), callee, arguments, names)
 }
 
 func (p *Parser) finishSubscript(object Expr) Expr {
-	index := p.nextExpr()
+	bracket := p.getPrev()
+	var index Expr = nil
+	if !p.check(COLON) {
+		index = p.nextExpr()
+	}
+	if p.match(COLON) {
+		return p.finishSlice(object, bracket, index)
+	}
 	if p.getCurrent().Token != RIGHT_SQUARE_BRACKET {
 		p.error("Expect ']' after array subscription")
 	}
@@ -414,37 +582,133 @@ func (p *Parser) finishSubscript(object Expr) Expr {
 	return NewSubscriptExpr(object, index, objectToken, indexToken)
 }
 
+// finishSlice parses the rest of `object[start:end:step]`, every part is optional
+func (p *Parser) finishSlice(object Expr, bracket Token, start Expr) Expr {
+	var end, step Expr = nil, nil
+	if !p.check(COLON) && !p.check(RIGHT_SQUARE_BRACKET) {
+		end = p.nextExpr()
+	}
+	if p.match(COLON) && !p.check(RIGHT_SQUARE_BRACKET) {
+		step = p.nextExpr()
+	}
+	if !p.match(RIGHT_SQUARE_BRACKET) {
+		p.error("Expect ']' after slice")
+	}
+	return NewSliceExpr(object, bracket, start, end, step)
+}
+
 func (p *Parser) call() Expr {
 	expr := p.group()
+	isOptionalChain := false
 	for true {
 		if p.match(LEFT_PAREN) {
 			expr = p.finishCall(expr)
 		} else if p.match(LEFT_SQUARE_BRACKET) {
 			expr = p.finishSubscript(expr)
-		} else if p.match(DOT) {
+		} else if p.match(QUESTION_LEFT_SQUARE_BRACKET) {
+			expr = p.finishSubscript(expr)
+			switch sub := expr.(type) {
+			case *SubscriptExpr:
+				sub.optional = true
+			case *SliceExpr:
+				sub.optional = true
+			}
+			isOptionalChain = true
+		} else if p.match(DOT, QUESTION_DOT) {
+			optional := p.getPrev().Token == QUESTION_DOT
+			isOptionalChain = isOptionalChain || optional
+			if optional && p.match(LEFT_PAREN) {
+				call := p.finishCall(expr).(*CallExpr)
+				call.optional = true
+				expr = call
+				continue
+			}
 			name := p.getCurrent()
 			p.currentIndex++
 			if name.Token != IDENTIFIER {
 				p.error("Expect property name after '.'")
 			}
-			expr = NewGetExpr(expr, name)
+			get := NewGetExpr(expr, name)
+			get.optional = optional
+			expr = get
 		} else {
 			break
 		}
 	}
+	if isOptionalChain {
+		return NewOptionalChainExpr(expr)
+	}
 	return expr
 }
 
 func (p *Parser) unary() Expr {
-	if p.match(MINUS, BANG) {
+	if p.match(MINUS, BANG, TILDE) {
 		token := p.getPrev()
 		expr := p.unary()
 		if expr == nil {
 			return nil
 		}
 		return NewUnaryExpr(token, expr)
+	} else if p.match(PLUS_PLUS, MINUS_MINUS) {
+		// ++x is x += 1
+		token := p.getPrev()
+		target := p.unary()
+		return p.assignTarget(target, incrementOperator(token), NewLiteralExpr(int64(1)))
+	} else if p.match(AWAIT) {
+		keyword := p.getPrev()
+		return NewAwaitExpr(keyword, p.unary())
+	} else if p.match(SPAWN) {
+		keyword := p.getPrev()
+		call, ok := p.call().(*CallExpr)
+		if !ok {
+			p.error("Expect function call after spawn")
+		}
+		return NewSpawnExpr(keyword, call)
+	}
+	return p.power()
+}
+
+// '**' binds tighter than unary minus on its left and is right associative:
+// -2 ** 2 is -(2 ** 2), 2 ** 3 ** 2 is 2 ** (3 ** 2)
+func (p *Parser) power() Expr {
+	expr := p.postfix()
+	if expr == nil {
+		return nil
+	}
+	if p.match(STAR_STAR) {
+		operator := p.getPrev()
+		right := p.unary()
+		if right == nil {
+			return nil
+		}
+		expr = NewBinaryExpr(expr, operator, right)
 	}
-	return p.call()
+	return expr
+}
+
+// x++ is (x += 1) - 1, so the old value is the result
+func (p *Parser) postfix() Expr {
+	expr := p.call()
+	if p.match(PLUS_PLUS, MINUS_MINUS) {
+		update := p.assignTarget(expr, incrementOperator(p.getPrev()), NewLiteralExpr(int64(1)))
+		switch update := update.(type) {
+		case *AssignExpr:
+			update.postfix = true
+		case *SetExpr:
+			update.postfix = true
+		case *SubscriptSetExpr:
+			update.postfix = true
+		}
+		return update
+	}
+	return expr
+}
+
+func incrementOperator(token Token) Token {
+	if token.Token == PLUS_PLUS {
+		return *NewToken("+=", PLUS_EQUAL, nil, token.Line)
+	}
+	return *NewToken("-=", MINUS_EQUAL, nil, token.Line)
 }
 
 func (p *Parser) factor() Expr {
@@ -452,7 +716,7 @@ func (p *Parser) factor() Expr {
 	if expr == nil {
 		return nil
 	}
-	for p.match(STAR, SLASH, PERCENT) {
+	for p.match(STAR, SLASH, PERCENT, TILDE_SLASH) {
 		operator := p.getPrev()
 		right := p.unary()
 		if right == nil {
@@ -479,12 +743,12 @@ func (p *Parser) term() Expr {
 	return expr
 }
 
-func (p *Parser) comparison() Expr {
+func (p *Parser) shift() Expr {
 	expr := p.term()
 	if expr == nil {
 		return nil
 	}
-	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+	for p.match(LESS_LESS, GREATER_GREATER) {
 		operator := p.getPrev()
 		right := p.term()
 		if right == nil {
@@ -495,6 +759,72 @@ func (p *Parser) comparison() Expr {
 	return expr
 }
 
+func (p *Parser) bitwiseAnd() Expr {
+	expr := p.shift()
+	if expr == nil {
+		return nil
+	}
+	for p.match(AMPERSAND) {
+		operator := p.getPrev()
+		right := p.shift()
+		if right == nil {
+			return nil
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+	return expr
+}
+
+func (p *Parser) bitwiseXor() Expr {
+	expr := p.bitwiseAnd()
+	if expr == nil {
+		return nil
+	}
+	for p.match(CARET) {
+		operator := p.getPrev()
+		right := p.bitwiseAnd()
+		if right == nil {
+			return nil
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+	return expr
+}
+
+// bitwise operators bind tighter than comparisons,
+// so `x & 1 == 0` means `(x & 1) == 0`
+func (p *Parser) bitwiseOr() Expr {
+	expr := p.bitwiseXor()
+	if expr == nil {
+		return nil
+	}
+	for p.match(PIPE) {
+		operator := p.getPrev()
+		right := p.bitwiseXor()
+		if right == nil {
+			return nil
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+	return expr
+}
+
+func (p *Parser) comparison() Expr {
+	expr := p.bitwiseOr()
+	if expr == nil {
+		return nil
+	}
+	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
+		operator := p.getPrev()
+		right := p.bitwiseOr()
+		if right == nil {
+			return nil
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+	return expr
+}
+
 func (p *Parser) equality() Expr {
 	expr := p.comparison()
 	if expr == nil {
@@ -531,25 +861,81 @@ func (p *Parser) or() Expr {
 	return expr
 }
 
-func (p *Parser) assignment() Expr {
+func (p *Parser) nullish() Expr {
 	expr := p.or()
+	for p.match(QUESTION_QUESTION) {
+		operator := p.getPrev()
+		right := p.or()
+		expr = NewLogicalExpr(expr, operator, right)
+	}
+	return expr
+}
 
-	if p.match(EQUAL) {
-		value := p.assignment()
-		switch expr := expr.(type) {
-		case *VarExpr:
-			name := expr.name
-			return NewAssignExpr(name, value)
-		case *GetExpr:
-			get := expr
-			return NewSetExpr(get.object, get.name, value)
-		default:
-			p.error("Invalid assignment target")
+func (p *Parser) ternary() Expr {
+	expr := p.nullish()
+	if p.match(QUESTION) {
+		question := p.getPrev()
+		thenBranch := p.nextExpr()
+		if !p.match(COLON) {
+			p.error("Expect ':' after then branch of conditional expression")
 		}
+		elseBranch := p.ternary()
+		return NewTernaryExpr(question, expr, thenBranch, elseBranch)
+	}
+	return expr
+}
+
+func (p *Parser) assignment() Expr {
+	if p.match(YIELD) {
+		return p.yieldExpr()
+	}
+	expr := p.ternary()
+
+	if p.match(EQUAL, PLUS_EQUAL, MINUS_EQUAL, STAR_EQUAL, SLASH_EQUAL, PERCENT_EQUAL) {
+		operator := p.getPrev()
+		value := p.assignment()
+		return p.assignTarget(expr, operator, value)
 	}
 	return expr
 }
 
+// yieldExpr parses `yield` with an optional value,
+// the value is omitted when the yield ends the expression.
+func (p *Parser) yieldExpr() Expr {
+	keyword := p.getPrev()
+	switch p.getCurrent().Token {
+	case SEMICOLON, RIGHT_PAREN, RIGHT_SQUARE_BRACKET, RIGHT_BRACE, COMMA, COLON:
+		return NewYieldExpr(keyword, nil)
+	}
+	return NewYieldExpr(keyword, p.nextExpr())
+}
+
+func (p *Parser) assignTarget(target Expr, operator Token, value Expr) Expr {
+	switch target := target.(type) {
+	case *VarExpr:
+		return NewAssignExpr(target.name, operator, value)
+	case *GetExpr:
+		return NewSetExpr(target.object, target.name, operator, value)
+	case *SubscriptExpr:
+		return NewSubscriptSetExpr(target, operator, value)
+	case *ArrayDeclExpr:
+		if operator.Token != EQUAL {
+			p.error("Destructuring assignment supports only '='")
+		}
+		for _, element := range target.elements {
+			switch element.(type) {
+			case *VarExpr, *GetExpr, *SubscriptExpr:
+			default:
+				p.error("Invalid assignment target")
+			}
+		}
+		return NewDestructureAssignExpr(operator, target.elements, value)
+	default:
+		p.error("Invalid assignment target")
+	}
+	return nil
+}
+
 func (p *Parser) nextExpr() Expr {
 	return p.assignment()
 }
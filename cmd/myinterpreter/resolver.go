@@ -44,14 +44,20 @@ func (ct _classType) Subclass() int {
 type Resolver struct {
 	interpreter     *Interpreter
 	scopes          []map[string]bool
+	constants       []map[string]bool
+	globalConstants map[string]bool
 	currentFunction int
 	currentClass    int
+	// declaration of the function being resolved, yield marks it as generator
+	currentDeclaration *Function
 }
 
 func NewResolver(i *Interpreter) *Resolver {
 	r := new(Resolver)
 	r.interpreter = i
 	r.scopes = make([]map[string]bool, 0)
+	r.constants = make([]map[string]bool, 0)
+	r.globalConstants = make(map[string]bool)
 	r.currentFunction = FunctionType.None()
 	r.currentClass = ClassType.None()
 	return r
@@ -59,10 +65,12 @@ func NewResolver(i *Interpreter) *Resolver {
 
 func (r *Resolver) beginScope() {
 	r.scopes = append(r.scopes, make(map[string]bool))
+	r.constants = append(r.constants, make(map[string]bool))
 }
 
 func (r *Resolver) endScope() {
 	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.constants = r.constants[:len(r.constants)-1]
 }
 
 func (r *Resolver) currentScope() map[string]bool {
@@ -87,14 +95,21 @@ func (r Resolver) resolveExpr(expr Expr) {
 }
 
 func (r *Resolver) resolveFunction(stmt *Function, type_ int) {
-	enclosingFunctionType := r.currentFunction
-	defer func() { r.currentFunction = enclosingFunctionType }()
-	r.currentFunction = type_
+	enclosingFunctionType, enclosingDeclaration := r.currentFunction, r.currentDeclaration
+	defer func() { r.currentFunction, r.currentDeclaration = enclosingFunctionType, enclosingDeclaration }()
+	r.currentFunction, r.currentDeclaration = type_, stmt
 	r.beginScope()
-	for _, param := range stmt.arguments {
+	for idx, param := range stmt.arguments {
+		if stmt.defaults[idx] != nil {
+			r.resolveExpr(stmt.defaults[idx])
+		}
 		r.declare(param)
 		r.define(param)
 	}
+	if stmt.rest != nil {
+		r.declare(*stmt.rest)
+		r.define(*stmt.rest)
+	}
 	r.resolveStmts(stmt.body.stmts)
 	r.endScope()
 }
@@ -114,6 +129,26 @@ func (r *Resolver) declare(name Token) {
 	}
 }
 
+// declareConstant marks an already declared name as constant.
+// Global constants can only be checked statically when they are
+// declared before the assignment, the rest is checked by State.
+func (r *Resolver) declareConstant(name Token) {
+	if len(r.scopes) == 0 {
+		r.globalConstants[name.Lexeme] = true
+		return
+	}
+	r.constants[len(r.constants)-1][name.Lexeme] = true
+}
+
+func (r *Resolver) isConstant(name Token) bool {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, found := r.scopes[i][name.Lexeme]; found {
+			return r.constants[i][name.Lexeme]
+		}
+	}
+	return r.globalConstants[name.Lexeme]
+}
+
 func (r *Resolver) resolveLocal(expr Expr, name Token) {
 	for i := len(r.scopes) - 1; i >= 0; i-- {
 		if _, found := r.scopes[i][name.Lexeme]; found {
@@ -131,14 +166,41 @@ func (r Resolver) visitBlockStmt(stmt *Block) {
 }
 
 func (r Resolver) visitVarStmt(stmt *Var) {
+	if len(r.scopes) == 0 && r.globalConstants[stmt.varName.Lexeme] {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't redeclare constant", stmt.varName.Line, stmt.varName.Lexeme))
+	}
 	r.declare(stmt.varName)
 	if stmt.varValue != nil {
 		r.resolveExpr(stmt.varValue)
 	}
 	r.define(stmt.varName)
+	if stmt.isConst {
+		r.declareConstant(stmt.varName)
+	}
+}
+
+func (r Resolver) visitDestructureStmt(stmt *Destructure) {
+	names := stmt.names
+	if stmt.rest != nil {
+		names = append(names[:len(names):len(names)], *stmt.rest)
+	}
+	for _, name := range names {
+		if len(r.scopes) == 0 && r.globalConstants[name.Lexeme] {
+			r.error(fmt.Sprintf("[line %v] Error at '%v': Can't redeclare constant", name.Line, name.Lexeme))
+		}
+		r.declare(name)
+	}
+	r.resolveExpr(stmt.value)
+	for _, name := range names {
+		r.define(name)
+		if stmt.isConst {
+			r.declareConstant(name)
+		}
+	}
 }
 
 func (r Resolver) visitClassStmt(stmt *Class) {
+	r.resolveDecorators(stmt.decorators)
 	enclosingClass := r.currentClass
 	r.currentClass = ClassType.Class()
 	r.declare(stmt.name)
@@ -155,6 +217,11 @@ func (r Resolver) visitClassStmt(stmt *Class) {
 		r.currentScope()["super"] = true
 	}
 
+	// method decorators are evaluated when the class is defined, outside of methods
+	for _, method := range stmt.methods {
+		r.resolveDecorators(method.decorators)
+	}
+
 	r.beginScope()
 	r.currentScope()["this"] = true
 
@@ -171,7 +238,14 @@ func (r Resolver) visitClassStmt(stmt *Class) {
 	r.currentClass = enclosingClass
 }
 
+func (r Resolver) resolveDecorators(decorators []Expr) {
+	for _, decorator := range decorators {
+		r.resolveExpr(decorator)
+	}
+}
+
 func (r Resolver) visitFunctionStmt(stmt *Function) {
+	r.resolveDecorators(stmt.decorators)
 	r.declare(stmt.name)
 	r.define(stmt.name)
 	r.resolveFunction(stmt, FunctionType.Function())
@@ -222,11 +296,25 @@ func (r Resolver) visitVarExpr(expr *VarExpr) any {
 }
 
 func (r Resolver) visitAssignExpr(expr *AssignExpr) any {
+	if r.isConstant(expr.name) {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't assign to constant", expr.name.Line, expr.name.Lexeme))
+	}
 	r.resolveExpr(expr.value)
 	r.resolveLocal(expr, expr.name)
 	return nil
 }
 
+func (r Resolver) visitDestructureAssignExpr(expr *DestructureAssignExpr) any {
+	r.resolveExpr(expr.value)
+	for _, target := range expr.targets {
+		if target, ok := target.(*VarExpr); ok && r.isConstant(target.name) {
+			r.error(fmt.Sprintf("[line %v] Error at '%v': Can't assign to constant", target.name.Line, target.name.Lexeme))
+		}
+		r.resolveExpr(target)
+	}
+	return nil
+}
+
 func (r Resolver) visitBinaryExpr(expr *BinaryExpr) any {
 	r.resolveExpr(expr.left)
 	r.resolveExpr(expr.right)
@@ -249,6 +337,72 @@ func (r Resolver) visitSubscriptExpr(expr *SubscriptExpr) any {
 	return nil
 }
 
+func (r Resolver) visitSubscriptSetExpr(expr *SubscriptSetExpr) any {
+	r.resolveExpr(expr.value)
+	r.resolveExpr(expr.object)
+	r.resolveExpr(expr.index)
+	return nil
+}
+
+func (r Resolver) visitTernaryExpr(expr *TernaryExpr) any {
+	r.resolveExpr(expr.condition)
+	r.resolveExpr(expr.thenBranch)
+	r.resolveExpr(expr.elseBranch)
+	return nil
+}
+
+func (r Resolver) visitOptionalChainExpr(expr *OptionalChainExpr) any {
+	r.resolveExpr(expr.expr)
+	return nil
+}
+
+func (r Resolver) visitSliceExpr(expr *SliceExpr) any {
+	r.resolveExpr(expr.object)
+	for _, bound := range []Expr{expr.start, expr.end, expr.step} {
+		if bound != nil {
+			r.resolveExpr(bound)
+		}
+	}
+	return nil
+}
+
+// await is allowed in async functions and at the top level,
+// where it runs the event loop until the promise is resolved.
+func (r Resolver) visitAwaitExpr(expr *AwaitExpr) any {
+	if r.currentFunction != FunctionType.None() && !r.currentDeclaration.isAsync {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't await outside of async function", expr.keyword.Line, expr.keyword.Lexeme))
+	}
+	r.resolveExpr(expr.value)
+	return nil
+}
+
+func (r Resolver) visitSpawnExpr(expr *SpawnExpr) any {
+	r.resolveExpr(expr.call)
+	return nil
+}
+
+func (r Resolver) visitYieldExpr(expr *YieldExpr) any {
+	if r.currentFunction == FunctionType.None() {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't yield outside of a function", expr.keyword.Line, expr.keyword.Lexeme))
+	}
+	if r.currentFunction == FunctionType.Initializer() {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't yield from initializer", expr.keyword.Line, expr.keyword.Lexeme))
+	}
+	if r.currentDeclaration.isAsync {
+		r.error(fmt.Sprintf("[line %v] Error at '%v': Can't yield from async function", expr.keyword.Line, expr.keyword.Lexeme))
+	}
+	r.currentDeclaration.isGenerator = true
+	if expr.value != nil {
+		r.resolveExpr(expr.value)
+	}
+	return nil
+}
+
+func (r Resolver) visitSpreadExpr(expr *SpreadExpr) any {
+	r.resolveExpr(expr.value)
+	return nil
+}
+
 func (r Resolver) visitGetExpr(expr *GetExpr) any {
 	r.resolveExpr(expr.object)
 	return nil
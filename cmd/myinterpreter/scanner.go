@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
@@ -63,21 +64,84 @@ COMMENTS_AGAGIN:
 	case ']':
 		return NewToken("]", RIGHT_SQUARE_BRACKET, nil, s.CurrentLine), nil
 	case '*':
+		if s.peek() == '*' {
+			s.CurrentIndex++
+			return NewToken("**", STAR_STAR, nil, s.CurrentLine), nil
+		} else if s.peek() == '=' {
+			s.CurrentIndex++
+			return NewToken("*=", STAR_EQUAL, nil, s.CurrentLine), nil
+		}
 		return NewToken("*", STAR, nil, s.CurrentLine), nil
 	case '.':
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.CurrentIndex += 2
+			return NewToken("...", ELLIPSIS, nil, s.CurrentLine), nil
+		}
 		return NewToken(".", DOT, nil, s.CurrentLine), nil
 	case ',':
 		return NewToken(",", COMMA, nil, s.CurrentLine), nil
 	case '+':
+		if s.peek() == '+' {
+			s.CurrentIndex++
+			return NewToken("++", PLUS_PLUS, nil, s.CurrentLine), nil
+		} else if s.peek() == '=' {
+			s.CurrentIndex++
+			return NewToken("+=", PLUS_EQUAL, nil, s.CurrentLine), nil
+		}
 		return NewToken("+", PLUS, nil, s.CurrentLine), nil
 	case '-':
+		if s.peek() == '-' {
+			s.CurrentIndex++
+			return NewToken("--", MINUS_MINUS, nil, s.CurrentLine), nil
+		} else if s.peek() == '=' {
+			s.CurrentIndex++
+			return NewToken("-=", MINUS_EQUAL, nil, s.CurrentLine), nil
+		}
 		return NewToken("-", MINUS, nil, s.CurrentLine), nil
 	case '/':
+		if s.peek() == '=' {
+			s.CurrentIndex++
+			return NewToken("/=", SLASH_EQUAL, nil, s.CurrentLine), nil
+		}
 		return NewToken("/", SLASH, nil, s.CurrentLine), nil
 	case '%':
+		if s.peek() == '=' {
+			s.CurrentIndex++
+			return NewToken("%=", PERCENT_EQUAL, nil, s.CurrentLine), nil
+		}
 		return NewToken("%", PERCENT, nil, s.CurrentLine), nil
+	case '&':
+		return NewToken("&", AMPERSAND, nil, s.CurrentLine), nil
+	case '|':
+		return NewToken("|", PIPE, nil, s.CurrentLine), nil
+	case '^':
+		return NewToken("^", CARET, nil, s.CurrentLine), nil
+	case '~':
+		// `//` starts a comment, so integer division is spelled `~/`
+		if s.peek() == '/' {
+			s.CurrentIndex++
+			return NewToken("~/", TILDE_SLASH, nil, s.CurrentLine), nil
+		}
+		return NewToken("~", TILDE, nil, s.CurrentLine), nil
+	case '?':
+		// `c ?.5 : 1` and `c ?[1] : x` are ternaries only when written with a space
+		if s.peek() == '?' {
+			s.CurrentIndex++
+			return NewToken("??", QUESTION_QUESTION, nil, s.CurrentLine), nil
+		} else if s.peek() == '.' && !isDigit(s.peekNext()) {
+			s.CurrentIndex++
+			return NewToken("?.", QUESTION_DOT, nil, s.CurrentLine), nil
+		} else if s.peek() == '[' {
+			s.CurrentIndex++
+			return NewToken("?[", QUESTION_LEFT_SQUARE_BRACKET, nil, s.CurrentLine), nil
+		}
+		return NewToken("?", QUESTION, nil, s.CurrentLine), nil
+	case ':':
+		return NewToken(":", COLON, nil, s.CurrentLine), nil
 	case ';':
 		return NewToken(";", SEMICOLON, nil, s.CurrentLine), nil
+	case '@':
+		return NewToken("@", AT, nil, s.CurrentLine), nil
 	case '=':
 		if s.CurrentIndex < len(s.Source) && s.Source[s.CurrentIndex] == '=' {
 			s.CurrentIndex++
@@ -96,6 +160,9 @@ COMMENTS_AGAGIN:
 		if s.CurrentIndex < len(s.Source) && s.Source[s.CurrentIndex] == '=' {
 			s.CurrentIndex++
 			return NewToken("<=", LESS_EQUAL, nil, s.CurrentLine), nil
+		} else if s.CurrentIndex < len(s.Source) && s.Source[s.CurrentIndex] == '<' {
+			s.CurrentIndex++
+			return NewToken("<<", LESS_LESS, nil, s.CurrentLine), nil
 		} else {
 			return NewToken("<", LESS, nil, s.CurrentLine), nil
 		}
@@ -103,6 +170,9 @@ COMMENTS_AGAGIN:
 		if s.CurrentIndex < len(s.Source) && s.Source[s.CurrentIndex] == '=' {
 			s.CurrentIndex++
 			return NewToken(">=", GREATER_EQUAL, nil, s.CurrentLine), nil
+		} else if s.CurrentIndex < len(s.Source) && s.Source[s.CurrentIndex] == '>' {
+			s.CurrentIndex++
+			return NewToken(">>", GREATER_GREATER, nil, s.CurrentLine), nil
 		} else {
 			return NewToken(">", GREATER, nil, s.CurrentLine), nil
 		}
@@ -129,30 +199,7 @@ COMMENTS_AGAGIN:
 		return nil, nil
 	default:
 		if isDigit(char) {
-			var digits []rune
-			var isFloat bool = false
-
-			digits = append(digits, char)
-			for s.CurrentIndex < len(s.Source) && (isDigit(s.Source[s.CurrentIndex]) || (s.CurrentIndex < len(s.Source)-1 && s.Source[s.CurrentIndex] == '.' && isDigit(s.Source[s.CurrentIndex+1]))) {
-				if s.Source[s.CurrentIndex] == '.' && isFloat == false {
-					isFloat = true
-				} else if s.Source[s.CurrentIndex] == '.' {
-					break
-				}
-				digits = append(digits, s.Source[s.CurrentIndex])
-				s.CurrentIndex++
-			}
-
-			numLiteral := string(digits)
-			if !isFloat {
-				digits = append(digits, '.')
-				digits = append(digits, '0')
-			}
-			parsed, err := strconv.ParseFloat(string(digits), 64)
-			if err != nil {
-				return nil, errors.New("Can't parse NUMBER")
-			}
-			return NewToken(numLiteral, NUMBER, parsed, s.CurrentLine), nil
+			return s.number(char)
 		} else if isAlpha(char) {
 			var identifier []rune
 			identifier = append(identifier, char)
@@ -174,6 +221,124 @@ COMMENTS_AGAGIN:
 	}
 }
 
+// number scans decimal, hex (0x), binary (0b) and octal (0o) literals.
+// Underscores are allowed between digits, decimal literals may have
+// a fraction and an exponent, which makes them floats.
+func (s *Scanner) number(first rune) (*Token, error) {
+	start := s.CurrentIndex - 1
+	base := 10
+	if first == '0' && s.CurrentIndex < len(s.Source) {
+		switch s.Source[s.CurrentIndex] {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+	}
+
+	if base != 10 {
+		s.CurrentIndex++
+		digits := s.digits(base)
+		if len(digits) == 0 {
+			s.ExitCode = 65
+			return nil, errors.New(fmt.Sprintf("[line %v] Error: Invalid number literal: %v", s.CurrentLine, string(s.Source[start:s.CurrentIndex])))
+		}
+		return NewToken(string(s.Source[start:s.CurrentIndex]), NUMBER, parseInteger(digits, base), s.CurrentLine), nil
+	}
+
+	s.CurrentIndex--
+	digits := s.digits(10)
+	isFloat := false
+	if s.peek() == '.' && isDigit(s.peekNext()) {
+		isFloat = true
+		s.CurrentIndex++
+		digits += "." + s.digits(10)
+	}
+	if s.peek() == 'e' || s.peek() == 'E' {
+		exponentStart := s.CurrentIndex
+		s.CurrentIndex++
+		sign := ""
+		if s.peek() == '+' || s.peek() == '-' {
+			sign = string(s.peek())
+			s.CurrentIndex++
+		}
+		if isDigit(s.peek()) {
+			isFloat = true
+			digits += "e" + sign + s.digits(10)
+		} else {
+			// not an exponent, e.g. `1else`
+			s.CurrentIndex = exponentStart
+		}
+	}
+
+	numLiteral := string(s.Source[start:s.CurrentIndex])
+	if isFloat {
+		parsed, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			s.ExitCode = 65
+			return nil, errors.New(fmt.Sprintf("[line %v] Error: Can't parse NUMBER %v", s.CurrentLine, numLiteral))
+		}
+		return NewToken(numLiteral, NUMBER, parsed, s.CurrentLine), nil
+	}
+	return NewToken(numLiteral, NUMBER, parseInteger(digits, 10), s.CurrentLine), nil
+}
+
+// digits consumes digits of the base and underscores between them.
+func (s *Scanner) digits(base int) string {
+	var digits []rune
+	for s.CurrentIndex < len(s.Source) {
+		char := s.Source[s.CurrentIndex]
+		if char == '_' && len(digits) > 0 && isDigitOfBase(s.peekNext(), base) {
+			s.CurrentIndex++
+			continue
+		}
+		if !isDigitOfBase(char, base) {
+			break
+		}
+		digits = append(digits, char)
+		s.CurrentIndex++
+	}
+	return string(digits)
+}
+
+func (s *Scanner) peek() rune {
+	if s.CurrentIndex >= len(s.Source) {
+		return 0
+	}
+	return s.Source[s.CurrentIndex]
+}
+
+func (s *Scanner) peekNext() rune {
+	if s.CurrentIndex+1 >= len(s.Source) {
+		return 0
+	}
+	return s.Source[s.CurrentIndex+1]
+}
+
+// parseInteger returns int64, or bigint when the literal doesn't fit.
+func parseInteger(digits string, base int) any {
+	parsed, err := strconv.ParseInt(digits, base, 64)
+	if err == nil {
+		return parsed
+	}
+	bigLiteral, _ := new(big.Int).SetString(digits, base)
+	return bigLiteral
+}
+
+func isDigitOfBase(char rune, base int) bool {
+	switch base {
+	case 2:
+		return char == '0' || char == '1'
+	case 8:
+		return char >= '0' && char <= '7'
+	case 16:
+		return isDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+	}
+	return isDigit(char)
+}
+
 func isDigit(char rune) bool {
 	if char >= '0' && char <= '9' {
 		return true
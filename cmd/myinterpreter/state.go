@@ -2,39 +2,66 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"sync"
 )
 
+// State is a scope, spawned tasks share scopes of their closures,
+// so maps are guarded by mu.
 type State struct {
 	enclosing *State
 	values    map[string]any
+	constants map[string]bool
+	mu        sync.RWMutex
 }
 
 func NewState(enclosing *State) *State {
 	return &State{
 		enclosing: enclosing,
 		values:    make(map[string]any),
+		constants: make(map[string]bool),
 	}
 }
 
-func (s *State) assign(name string, value any) {
+// assign returns false when name is a constant, the caller reports
+// the error at the assignment.
+func (s *State) assign(name string, value any) bool {
+	s.mu.Lock()
 	if _, exist := s.values[name]; exist {
+		defer s.mu.Unlock()
+		if s.constants[name] {
+			return false
+		}
 		s.values[name] = value
-		return
+		return true
 	}
+	s.mu.Unlock()
 	if s.enclosing != nil {
-		s.enclosing.assign(name, value)
-		return
+		return s.enclosing.assign(name, value)
 	}
 	s.error("can't assign to variable that didnt exist")
+	return false
 }
 
 func (s *State) define(name string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.constants[name] {
+		s.error(fmt.Sprintf("can't redeclare constant '%v'", name))
+	}
 	s.values[name] = value
 }
 
+func (s *State) defineConst(name string, value any) {
+	s.define(name, value)
+	s.mu.Lock()
+	s.constants[name] = true
+	s.mu.Unlock()
+}
+
 func (s *State) access(name string) any {
+	s.mu.RLock()
 	value, exist := s.values[name]
+	s.mu.RUnlock()
 	if !exist {
 		if s.enclosing != nil {
 			return s.enclosing.access(name)
@@ -44,6 +71,13 @@ func (s *State) access(name string) any {
 	return value
 }
 
+// local returns the value defined in this scope only, nil if there is none.
+func (s *State) local(name string) any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[name]
+}
+
 func (s *State) ancestor(distance int) *State {
 	env := s
 	for range distance {
@@ -59,11 +93,10 @@ func (s *State) accessAt(distance int, name string) any {
 	return s.ancestor(distance).access(name)
 }
 
-func (s *State) assignAt(distance int, name string, value any) {
-	s.ancestor(distance).assign(name, value)
+func (s *State) assignAt(distance int, name string, value any) bool {
+	return s.ancestor(distance).assign(name, value)
 }
 
-func (s State) error(msg string) {
-	fmt.Fprintln(os.Stderr, msg)
-	os.Exit(70)
+func (s *State) error(msg string) {
+	panic(runtimeError{msg})
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdin is shared by all natives, so buffered data isn't lost
+// when a script mixes readLine() and readAll().
+var stdin = bufio.NewReader(os.Stdin)
+
+func readStdinLine() any {
+	line, err := stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil
+	} else if err != nil && err != io.EOF {
+		return NewLoxError(err.Error())
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+var stdinNatives = map[string]*NativeFunction{
+	// input(prompt) prints the prompt and reads one line, nil at the end of input
+	"input": NewNativeFunction(0, 1, func(i Interpreter, args []any) any {
+		if len(args) == 1 {
+			fmt.Print(i.stringArg(args, 0))
+		}
+		return readStdinLine()
+	}),
+	// readLine returns the next line without line break, nil at the end of input
+	"readLine": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		return readStdinLine()
+	}),
+	"readAll": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return NewLoxError(err.Error())
+		}
+		return string(content)
+	}),
+}
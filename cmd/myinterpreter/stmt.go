@@ -10,6 +10,7 @@ type stmtVisitor interface {
 	visitClassStmt(stmt *Class)
 	visitFunctionStmt(stmt *Function)
 	visitReturnStmt(stmt *Return)
+	visitDestructureStmt(stmt *Destructure)
 }
 
 type Stmt interface {
@@ -47,12 +48,14 @@ func (e *Expression) accept(vis stmtVisitor) {
 type Var struct {
 	varName  Token
 	varValue Expr
+	isConst  bool
 }
 
-func NewVar(varName Token, varValue Expr) *Var {
+func NewVar(varName Token, varValue Expr, isConst bool) *Var {
 	v := new(Var)
 	v.varName = varName
 	v.varValue = varValue
+	v.isConst = isConst
 	return v
 }
 
@@ -108,16 +111,26 @@ func (w *While) accept(vis stmtVisitor) {
 	vis.visitWhileStmt(w)
 }
 
+// Function declaration, defaults holds default value for every
+// parameter (nil when there is none), rest is the ...rest parameter.
 type Function struct {
-	name      Token
-	arguments []Token
-	body      *Block
+	name       Token
+	arguments  []Token
+	defaults   []Expr
+	rest       *Token
+	body       *Block
+	decorators []Expr
+	// isGenerator is set by Resolver when the body contains yield
+	isGenerator bool
+	isAsync     bool
 }
 
-func NewFunction(name Token, arguments []Token, body *Block) *Function {
+func NewFunction(name Token, arguments []Token, defaults []Expr, rest *Token, body *Block) *Function {
 	fn := new(Function)
 	fn.name = name
 	fn.arguments = arguments
+	fn.defaults = defaults
+	fn.rest = rest
 	fn.body = body
 	return fn
 }
@@ -146,6 +159,7 @@ type Class struct {
 	name       Token
 	superclass *VarExpr
 	methods    []*Function
+	decorators []Expr
 }
 
 func NewClass(name Token, superclass *VarExpr, methods []*Function) *Class {
@@ -159,3 +173,29 @@ func NewClass(name Token, superclass *VarExpr, methods []*Function) *Class {
 func (cls *Class) accept(vis stmtVisitor) {
 	vis.visitClassStmt(cls)
 }
+
+// Destructure is `var [a, b, ...rest] = value;` or `var {x, y} = value;`
+// rest is nil when there is no rest element.
+type Destructure struct {
+	keyword  Token
+	names    []Token
+	rest     *Token
+	isObject bool
+	value    Expr
+	isConst  bool
+}
+
+func NewDestructure(keyword Token, names []Token, rest *Token, isObject bool, value Expr, isConst bool) *Destructure {
+	return &Destructure{
+		keyword:  keyword,
+		names:    names,
+		rest:     rest,
+		isObject: isObject,
+		value:    value,
+		isConst:  isConst,
+	}
+}
+
+func (d *Destructure) accept(vis stmtVisitor) {
+	vis.visitDestructureStmt(d)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// stringifier renders lox values the way print shows them.
+// Strings inside arrays are quoted and escaped so ["1"] and [1] look
+// different and embedded quotes or newlines stay unambiguous,
+// arrays that contain themselves are shown as [...].
+// interpreter is nil when there is no way to call a user defined toString.
+type stringifier struct {
+	interpreter *Interpreter
+	seen        map[*LoxArray]bool
+}
+
+func (s stringifier) stringify(value any, nested bool) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case int64, float64, *big.Int, *LoxDecimal:
+		return formatNumber(v)
+	case string:
+		if nested {
+			return strconv.Quote(v)
+		}
+		return v
+	case *LoxArray:
+		if s.seen[v] {
+			return "[...]"
+		}
+		s.seen[v] = true
+		defer delete(s.seen, v)
+		values := v.snapshot()
+		elements := make([]string, len(values))
+		for idx, element := range values {
+			elements[idx] = s.stringify(element, true)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *LoxInstance:
+		if s.interpreter != nil {
+			if method := v.cls.findMethod("toString"); method != nil {
+				i := *s.interpreter
+				res, ok := i.callValue(method.declaration.name, i.bindMethod(v, method), []any{}).(string)
+				if !ok {
+					i.error(method.declaration.name, "toString should return a string")
+				}
+				return res
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// stringify is the single place where lox values are turned into text
+// for print, str, println, format's %v and the evaluate command.
+// Lox has no string interpolation or REPL yet, they should use it too.
+func (i Interpreter) stringify(value any) string {
+	return stringifier{interpreter: &i, seen: make(map[*LoxArray]bool)}.stringify(value, false)
+}
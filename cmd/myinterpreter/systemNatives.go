@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// setArgs exposes command line arguments following the script name
+// as the global args array.
+func (i *Interpreter) setArgs(args []string) {
+	elements := make([]any, len(args))
+	for idx, arg := range args {
+		elements[idx] = arg
+	}
+	i.globals.define("args", NewLoxArray(elements))
+}
+
+var systemNatives = map[string]*NativeFunction{
+	// getenv returns nil when the variable is not set
+	"getenv": NewNativeFunction(1, 1, func(i Interpreter, args []any) any {
+		value, ok := os.LookupEnv(i.stringArg(args, 0))
+		if !ok {
+			return nil
+		}
+		return value
+	}),
+	"setenv": NewNativeFunction(2, 2, func(i Interpreter, args []any) any {
+		if err := os.Setenv(i.stringArg(args, 0), i.stringArg(args, 1)); err != nil {
+			return NewLoxError(err.Error())
+		}
+		return nil
+	}),
+	// environ returns [name, value] pairs of all environment variables
+	"environ": NewNativeFunction(0, 0, func(i Interpreter, args []any) any {
+		env := os.Environ()
+		res := make([]any, len(env))
+		for idx, variable := range env {
+			name, value, _ := strings.Cut(variable, "=")
+			res[idx] = NewLoxArray([]any{name, value})
+		}
+		return NewLoxArray(res)
+	}),
+	// exit() or exit(code) stops the script immediately
+	"exit": NewNativeFunction(0, 1, func(i Interpreter, args []any) any {
+		if len(args) == 0 {
+			os.Exit(0)
+		}
+		code, ok := args[0].(int64)
+		if !ok {
+			i.error(i.callToken, "Exit code should be an integer")
+		}
+		os.Exit(int(code))
+		return nil
+	}),
+}
@@ -19,6 +19,28 @@ const (
 	MINUS
 	SLASH
 	PERCENT
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	LESS_LESS
+	GREATER_GREATER
+	STAR_STAR
+	TILDE_SLASH
+	PLUS_PLUS
+	MINUS_MINUS
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	QUESTION
+	QUESTION_QUESTION
+	QUESTION_DOT
+	QUESTION_LEFT_SQUARE_BRACKET
+	COLON
+	ELLIPSIS
+	AT
 	SEMICOLON
 	EQUAL
 	EQUAL_EQUAL
@@ -47,6 +69,11 @@ const (
 	NIL
 	PRINT
 	VAR
+	CONST
+	YIELD
+	SPAWN
+	ASYNC
+	AWAIT
 )
 
 func fillMap() *map[string]TokenType {
@@ -67,6 +94,11 @@ func fillMap() *map[string]TokenType {
 		"nil":    NIL,
 		"print":  PRINT,
 		"var":    VAR,
+		"const":  CONST,
+		"yield":  YIELD,
+		"spawn":  SPAWN,
+		"async":  ASYNC,
+		"await":  AWAIT,
 	}
 
 	return &res
@@ -77,11 +109,16 @@ func (tt TokenType) String() string {
 		"EOF", "LEFT_PAREN", "RIGHT_PAREN", "LEFT_BRACE", "RIGHT_BRACE",
 		"LEFT_SQUARE_BRACKET", "RIGHT_SQUARE_BRACKET",
 		"STAR", "DOT", "COMMA", "PLUS", "MINUS", "SLASH", "PERCENT",
+		"AMPERSAND", "PIPE", "CARET", "TILDE", "LESS_LESS", "GREATER_GREATER",
+		"STAR_STAR", "TILDE_SLASH", "PLUS_PLUS", "MINUS_MINUS",
+		"PLUS_EQUAL", "MINUS_EQUAL", "STAR_EQUAL", "SLASH_EQUAL", "PERCENT_EQUAL",
+		"QUESTION", "QUESTION_QUESTION", "QUESTION_DOT", "QUESTION_LEFT_SQUARE_BRACKET", "COLON",
+		"ELLIPSIS", "AT",
 		"SEMICOLON", "EQUAL", "EQUAL_EQUAL", "BANG", "BANG_EQUAL",
 		"LESS", "LESS_EQUAL", "GREATER", "GREATER_EQUAL",
 		"STRING", "NUMBER", "IDENTIFIER", "AND", "OR",
 		"CLASS", "SUPER", "THIS", "IF", "ELSE", "TRUE", "FALSE",
-		"FOR", "WHILE", "FUN", "RETURN", "NIL", "PRINT", "VAR",
+		"FOR", "WHILE", "FUN", "RETURN", "NIL", "PRINT", "VAR", "CONST", "YIELD", "SPAWN", "ASYNC", "AWAIT",
 	}[tt]
 }
 
@@ -108,15 +145,10 @@ func (t *Token) String() string {
 	if t.Literal == nil {
 		literalStr = "null"
 	} else {
-		switch v := t.Literal.(type) {
-		case float64:
-			if v == float64(int(v)) {
-				literalStr = fmt.Sprintf("%.1f", v)
-			} else {
-				literalStr = fmt.Sprintf("%v", v)
-			}
-		default:
-			literalStr = fmt.Sprintf("%v", v)
+		if isNumber(t.Literal) {
+			literalStr = formatLiteral(t.Literal)
+		} else {
+			literalStr = fmt.Sprintf("%v", t.Literal)
 		}
 	}
 	s := fmt.Sprintf("%v %v %v", t.Token, t.Lexeme, literalStr)